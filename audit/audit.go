@@ -0,0 +1,106 @@
+// Package audit keeps an append-only record of every command sent to a device
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shenjler/ssh_ping_exporter/config"
+)
+
+// Results of a command
+const (
+	ResultSuccess = "success"
+	ResultError   = "error"
+	ResultDenied  = "denied"
+)
+
+// Entry is the audit record of one command
+type Entry struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Device      string    `json:"device"`
+	Username    string    `json:"username"`
+	Command     string    `json:"command"`
+	Duration    float64   `json:"duration_seconds"`
+	OutputBytes int       `json:"output_bytes"`
+	Result      string    `json:"result"`
+	Error       string    `json:"error,omitempty"`
+	Output      string    `json:"output,omitempty"`
+	// BatchSize is the number of commands sent together in one batch, Duration is then the duration of the whole batch
+	BatchSize int `json:"batch_size,omitempty"`
+}
+
+var (
+	mu            sync.Mutex
+	writer        io.WriteCloser
+	captureOutput bool
+)
+
+// Log is an opened audit log, it takes effect with Use
+type Log struct {
+	writer        io.WriteCloser
+	captureOutput bool
+}
+
+// Open opens the audit log configured in cfg. A nil config disables the audit log.
+func Open(cfg *config.AuditConfig) (*Log, error) {
+	l := &Log{}
+	if cfg == nil {
+		return l, nil
+	}
+
+	var err error
+	switch {
+	case cfg.Syslog:
+		l.writer, err = openSyslog(cfg.SyslogTag)
+	case cfg.File != "":
+		l.writer, err = openFile(cfg.File, int64(cfg.MaxSizeMB)<<20, cfg.MaxBackups)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open audit log")
+	}
+	l.captureOutput = cfg.CaptureOutput
+
+	return l, nil
+}
+
+// Use makes l the audit log and closes the previous one
+func Use(l *Log) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if writer != nil {
+		writer.Close()
+	}
+	writer = l.writer
+	captureOutput = l.captureOutput
+}
+
+// Record writes e to the audit log. The output is only kept if output capture is enabled.
+func Record(e Entry) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if writer == nil {
+		return
+	}
+
+	if !captureOutput {
+		e.Output = ""
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		log.Printf("audit: could not encode entry: %s", err)
+		return
+	}
+
+	_, err = writer.Write(append(b, '\n'))
+	if err != nil {
+		log.Printf("audit: could not write entry: %s", err)
+	}
+}
@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+// rotatingFile appends to a file which is rotated to file.1 ... file.<backups> when it exceeds maxSize bytes
+type rotatingFile struct {
+	path    string
+	maxSize int64
+	backups int
+	file    *os.File
+	size    int64
+}
+
+func openFile(path string, maxSize int64, backups int) (*rotatingFile, error) {
+	f := &rotatingFile{path: path, maxSize: maxSize, backups: backups}
+
+	err := f.open()
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+
+	return nil
+}
+
+func (f *rotatingFile) Write(b []byte) (int, error) {
+	if f.file == nil || f.maxSize > 0 && f.size > 0 && f.size+int64(len(b)) > f.maxSize {
+		err := f.rotate()
+		if err != nil {
+			if f.file == nil {
+				return 0, err
+			}
+			log.Printf("audit: %s", err)
+		}
+	}
+
+	n, err := f.file.Write(b)
+	f.size += int64(n)
+
+	return n, err
+}
+
+// rotate shifts the backups by one, the oldest is removed. If that fails the file is reopened and the next write tries again.
+// file is nil if it could not be reopened.
+func (f *rotatingFile) rotate() error {
+	err := f.shift()
+	if err != nil {
+		err = fmt.Errorf("could not rotate %s: %s", f.path, err)
+	}
+
+	openErr := f.open()
+	if openErr != nil {
+		f.file = nil
+		if err != nil {
+			return fmt.Errorf("%s, could not reopen it: %s", err, openErr)
+		}
+		return openErr
+	}
+
+	return err
+}
+
+func (f *rotatingFile) shift() error {
+	err := f.file.Close()
+	if err != nil {
+		return err
+	}
+
+	if f.backups > 0 {
+		os.Remove(fmt.Sprintf("%s.%d", f.path, f.backups))
+		for i := f.backups - 1; i > 0; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", f.path, i), fmt.Sprintf("%s.%d", f.path, i+1))
+		}
+		return os.Rename(f.path, f.path+".1")
+	}
+
+	return os.Remove(f.path)
+}
+
+func (f *rotatingFile) Close() error {
+	if f.file == nil {
+		return nil
+	}
+
+	return f.file.Close()
+}
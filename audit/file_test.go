@@ -0,0 +1,86 @@
+package audit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotatingFileRotationFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		// block makes the rotation in dir fail and returns a function undoing it
+		block func(t *testing.T, dir string) func()
+	}{
+		{
+			name: "read-only directory",
+			block: func(t *testing.T, dir string) func() {
+				if os.Geteuid() == 0 {
+					t.Skip("permissions are not enforced for root")
+				}
+				if err := os.Chmod(dir, 0500); err != nil {
+					t.Fatal(err)
+				}
+				return func() { os.Chmod(dir, 0700) }
+			},
+		},
+		{
+			name: "backup is a directory",
+			block: func(t *testing.T, dir string) func() {
+				backup := filepath.Join(dir, "audit.log.1")
+				if err := os.MkdirAll(filepath.Join(backup, "keep"), 0700); err != nil {
+					t.Fatal(err)
+				}
+				return func() { os.RemoveAll(backup) }
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "audit")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			path := filepath.Join(dir, "audit.log")
+
+			f, err := openFile(path, 10, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			write(t, f, "first\n")
+			unblock := test.block(t, dir)
+			write(t, f, "second\n")
+			expectContent(t, path, "first\nsecond\n")
+			unblock()
+
+			write(t, f, "third\n")
+			expectContent(t, path, "third\n")
+			expectContent(t, path+".1", "first\nsecond\n")
+		})
+	}
+}
+
+func write(t *testing.T, f *rotatingFile, s string) {
+	t.Helper()
+
+	if _, err := f.Write([]byte(s)); err != nil {
+		t.Fatalf("could not write %q: %s", s, err)
+	}
+}
+
+func expectContent(t *testing.T, path, expected string) {
+	t.Helper()
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != expected {
+		t.Errorf("%s: got %q, expected %q", filepath.Base(path), b, expected)
+	}
+}
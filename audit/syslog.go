@@ -0,0 +1,17 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package audit
+
+import (
+	"io"
+	"log/syslog"
+)
+
+func openSyslog(tag string) (io.WriteCloser, error) {
+	if tag == "" {
+		tag = "ssh_ping_exporter"
+	}
+
+	return syslog.New(syslog.LOG_INFO|syslog.LOG_AUTH, tag)
+}
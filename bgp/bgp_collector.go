@@ -1,8 +1,6 @@
 package bgp
 
 import (
-	"log"
-
 	"github.com/shenjler/ssh_ping_exporter/rpc"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -24,6 +22,14 @@ func init() {
 	receivedPrefixesDesc = prometheus.NewDesc(prefix+"prefixes_received_count", "Number of received prefixes", l, nil)
 	inputMessagesDesc = prometheus.NewDesc(prefix+"messages_input_count", "Number of received messages", l, nil)
 	outputMessagesDesc = prometheus.NewDesc(prefix+"messages_output_count", "Number of transmitted messages", l, nil)
+
+	collector.Register(&collector.Registration{
+		Name: "bgp",
+		Help: "BGP sessions (message count, prefix counts per peer, session state)",
+		New: func(interface{}) (collector.RPCCollector, error) {
+			return NewCollector(), nil
+		},
+	})
 }
 
 type bgpCollector struct {
@@ -48,21 +54,20 @@ func (*bgpCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 // Collect collects metrics from Cisco
-func (c *bgpCollector) Collect(client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error {
-	out, err := client.RunCommand("show bgp all summary")
-	if err != nil {
-		return err
+func (c *bgpCollector) Collect(sc *collector.ScrapeContext, client *rpc.Client, ch chan<- prometheus.Metric) error {
+	cmd := client.Driver().BGP
+	if cmd == nil {
+		sc.Logger.Debugf("BGP is not supported (%s)", client.OSType)
+		return nil
 	}
-	items, err := c.Parse(client.OSType, out)
+
+	items, err := cmd.Run(client.Runner(sc.Context))
 	if err != nil {
-		if client.Debug {
-			log.Printf("Parse bgp sessions for %s: %s\n", labelValues[0], err.Error())
-		}
-		return nil
+		return err
 	}
 
 	for _, item := range items {
-		l := append(labelValues, item.Asn, item.IP)
+		l := sc.LabelValues(item.Asn, item.IP)
 
 		up := 0
 		if item.Up {
@@ -1,68 +1,121 @@
 package main
 
 import (
+	"context"
+	"net/url"
 	"time"
 
 	"sync"
 
+	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/log"
+	"github.com/shenjler/ssh_ping_exporter/collector"
+	"github.com/shenjler/ssh_ping_exporter/config"
 	"github.com/shenjler/ssh_ping_exporter/connector"
 	"github.com/shenjler/ssh_ping_exporter/rpc"
+	"github.com/shenjler/ssh_ping_exporter/transcript"
 )
 
 const prefix = "pccw_"
 
 var (
 	scrapeCollectorDurationDesc *prometheus.Desc
+	collectorSuccessDesc        *prometheus.Desc
+	collectorLastErrorDesc      *prometheus.Desc
+	collectorFailuresDesc       *prometheus.Desc
 	scrapeDurationDesc          *prometheus.Desc
 	upDesc                      *prometheus.Desc
+	queueWaitDesc               *prometheus.Desc
+	sessionsQueuedDesc          *prometheus.Desc
+	sessionsRejectedDesc        *prometheus.Desc
+	keepaliveFailuresDesc       *prometheus.Desc
+	connectFailureDesc          *prometheus.Desc
+	connectPhaseDurationDesc    *prometheus.Desc
+	loginBannerDesc             *prometheus.Desc
+	deviceOSDesc                *prometheus.Desc
+	lastLoginDesc               *prometheus.Desc
 )
 
 func init() {
-	upDesc = prometheus.NewDesc(prefix+"up", "Scrape of target was successful", []string{"target"}, nil)
+	upDesc = prometheus.NewDesc(prefix+"up", "Scrape of target was successful", []string{"target", "reason"}, nil)
 	scrapeDurationDesc = prometheus.NewDesc(prefix+"collector_duration_seconds", "Duration of a collector scrape for one target", []string{"target"}, nil)
 	scrapeCollectorDurationDesc = prometheus.NewDesc(prefix+"collect_duration_seconds", "Duration of a scrape by collector and target", []string{"target", "collector"}, nil)
+	collectorSuccessDesc = prometheus.NewDesc(prefix+"collector_success", "Collector ran without error for the target", []string{"target", "collector"}, nil)
+	collectorLastErrorDesc = prometheus.NewDesc(prefix+"collector_last_error_timestamp_seconds", "Time of the last error of the collector for the target", []string{"target", "collector"}, nil)
+	collectorFailuresDesc = prometheus.NewDesc(prefix+"collector_failures_total", "Number of collector runs failed by kind (command, parse)", []string{"target", "collector", "kind"}, nil)
+	queueWaitDesc = prometheus.NewDesc(prefix+"session_queue_wait_seconds", "Time the scrape waited for a free SSH session slot", []string{"target"}, nil)
+	sessionsQueuedDesc = prometheus.NewDesc(prefix+"sessions_queued_total", "Number of SSH sessions which had to wait for a free slot or the minimum login interval", []string{"target"}, nil)
+	keepaliveFailuresDesc = prometheus.NewDesc(prefix+"ssh_keepalive_failures_total", "Number of SSH keepalives not answered in time", []string{"target"}, nil)
+	connectFailureDesc = prometheus.NewDesc(prefix+"connect_failure", "Connection to target failed for the given reason", []string{"target", "reason"}, nil)
+	connectPhaseDurationDesc = prometheus.NewDesc(prefix+"connect_phase_duration_seconds", "Duration of a phase of establishing the connection (dial, handshake, auth, shell_ready)", []string{"target", "phase"}, nil)
+	sessionsRejectedDesc = prometheus.NewDesc(prefix+"sessions_rejected_total", "Number of SSH sessions not opened because they were still waiting at the scrape deadline", []string{"target"}, nil)
+	loginBannerDesc = prometheus.NewDesc(prefix+"login_banner_info", "First line of the SSH banner and the MOTD printed by the target at login, hash covers their full text", []string{"target", "banner", "motd", "hash"}, nil)
+	deviceOSDesc = prometheus.NewDesc(prefix+"device_os_info", "OS identified on the target or configured for it", []string{"target", "os", "version", "model", "hostname"}, nil)
+	lastLoginDesc = prometheus.NewDesc(prefix+"last_login_timestamp_seconds", "Time of the previous login as printed by the target at login (Linux, VRP), usually the previous scrape", []string{"target", "source"}, nil)
 }
 
 type ciscoCollector struct {
+	ctx        context.Context
+	cfg        *config.Config
 	devices    []*connector.Device
 	collectors *collectors
-	dest       string
+	params     url.Values
+	probe      collector.ProbeSettings
 }
 
-func newCiscoCollector(devices []*connector.Device, dest string) *ciscoCollector {
+func newCiscoCollector(ctx context.Context, cfg *config.Config, devices []*connector.Device, available []*availableCollector, params url.Values, probe collector.ProbeSettings) *ciscoCollector {
 	return &ciscoCollector{
+		ctx:        ctx,
+		cfg:        cfg,
 		devices:    devices,
-		collectors: collectorsForDevices(devices, cfg),
-		dest:       dest,
+		collectors: collectorsForDevices(devices, available, cfg),
+		params:     params,
+		probe:      probe,
 	}
 }
 
 // Describe implements prometheus.Collector interface
 func (c *ciscoCollector) Describe(ch chan<- *prometheus.Desc) {
-	ch <- upDesc
-	ch <- scrapeDurationDesc
-	ch <- scrapeCollectorDurationDesc
+	describeExporterMetrics(ch)
 
 	for _, col := range c.collectors.allEnabledCollectors() {
 		col.Describe(ch)
 	}
 }
 
+// describeExporterMetrics describes the metrics of the exporter itself, independent of the collectors
+func describeExporterMetrics(ch chan<- *prometheus.Desc) {
+	ch <- upDesc
+	ch <- scrapeDurationDesc
+	ch <- scrapeCollectorDurationDesc
+	ch <- collectorSuccessDesc
+	ch <- collectorLastErrorDesc
+	ch <- collectorFailuresDesc
+	ch <- queueWaitDesc
+	ch <- sessionsQueuedDesc
+	ch <- sessionsRejectedDesc
+	ch <- keepaliveFailuresDesc
+	ch <- connectFailureDesc
+	ch <- connectPhaseDurationDesc
+	ch <- loginBannerDesc
+	ch <- deviceOSDesc
+	ch <- lastLoginDesc
+}
+
 // Collect implements prometheus.Collector interface
 func (c *ciscoCollector) Collect(ch chan<- prometheus.Metric) {
 	wg := &sync.WaitGroup{}
 
 	wg.Add(len(c.devices))
 	for _, d := range c.devices {
-		go c.collectForHost(d, ch, wg)
+		go c.collectForHost(c.ctx, d, ch, wg)
 	}
 
 	wg.Wait()
 }
 
-func (c *ciscoCollector) collectForHost(device *connector.Device, ch chan<- prometheus.Metric, wg *sync.WaitGroup) {
+func (c *ciscoCollector) collectForHost(ctx context.Context, device *connector.Device, ch chan<- prometheus.Metric, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	l := []string{device.Host}
@@ -72,32 +125,164 @@ func (c *ciscoCollector) collectForHost(device *connector.Device, ch chan<- prom
 		ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, time.Since(t).Seconds(), l...)
 	}()
 
-	conn, err := connector.NewSSSHConnection(device, cfg)
+	var transport connector.Transport
+	if c.cfg.Transcripts.Replaying() {
+		r, err := c.openReplay(device)
+		if err != nil {
+			log.Errorf("%s: %s", device.Host, err)
+			ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 0, append(l, "transcript")...)
+			return
+		}
+		if info, ok := r.LoginInfo(); ok {
+			c.collectLoginInfo(ch, l, info)
+		}
+		transport = r
+	} else {
+		release, ok := c.acquireSession(ctx, device, ch, l)
+		if !ok {
+			return
+		}
+		defer release()
+		defer func() {
+			ch <- prometheus.MustNewConstMetric(keepaliveFailuresDesc, prometheus.CounterValue, connector.KeepaliveFailures(device.Address()), l...)
+		}()
+
+		conn, ok := c.connect(ctx, device, ch, l)
+		if !ok {
+			return
+		}
+		transport = conn
+
+		if c.cfg.Transcripts.Recording() {
+			transport = transcript.NewRecorder(conn, transcript.FileName(c.cfg.Transcripts.Directory, device.Address()))
+		}
+	}
+	defer transport.Close()
+
+	ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 1, append(l, "")...)
+
+	client := rpc.NewClient(transport, device.Username, c.cfg.Debug)
+	client.Privileged = privilegedForDevice(device.DeviceConfig, c.cfg)
+	var err error
+	if os := device.DeviceConfig.OS; os != nil {
+		err = client.SetOS(ctx, *os)
+	} else {
+		err = client.Identify(ctx)
+	}
 	if err != nil {
-		log.Errorln(err)
-		ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 0, l...)
-		return
+		log.Errorln(device.Host + ": " + err.Error())
+	} else {
+		info := client.Info
+		ch <- prometheus.MustNewConstMetric(deviceOSDesc, prometheus.GaugeValue, 1, append(l, info.OSType, info.Version, info.Model, info.Hostname)...)
 	}
-	defer conn.Close()
 
-	ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 1, l...)
+	sc := &collector.ScrapeContext{
+		Context: ctx,
+		Params:  c.params,
+		Probe:   c.probe,
+		Device:  collector.DeviceInfo{Host: device.Host, Config: device.DeviceConfig, LabelValues: l},
+		Logger:  collector.NewLogger(device.Host, c.cfg.Debug),
+	}
 
-	client := rpc.NewClient(conn, cfg.Debug)
-	// err = client.Identify()
-	// if err != nil {
-	// 	log.Errorln(device.Host + ": " + err.Error())
-	// 	return
-	// }
+	// collectors are labeled by the name used in the features and flags
+	for _, a := range c.collectors.collectorsForDevice(device) {
+		if ctx.Err() != nil {
+			log.Errorf("%s: scrape deadline exceeded, skipping remaining collectors", device.Host)
+			return
+		}
 
-	for _, col := range c.collectors.collectorsForDevice(device) {
 		ct := time.Now()
-		// err := col.Collect(client, ch, l)
-		err := col.CollectByDest(client, ch, l, c.dest)
+		err := a.collector.Collect(sc, client, ch)
+		if err != nil {
+			log.Errorf("%s: %s: %s", device.Host, a.name, err)
+		}
+
+		ch <- prometheus.MustNewConstMetric(scrapeCollectorDurationDesc, prometheus.GaugeValue, time.Since(ct).Seconds(), append(l, a.name)...)
+		c.collectStatus(ch, l, a.name, err)
+	}
+}
+
+// collectStatus records the outcome of a collector and reports it with the outcomes of previous scrapes
+func (c *ciscoCollector) collectStatus(ch chan<- prometheus.Metric, labelValues []string, name string, err error) {
+	status := statuses.record(labelValues[0], name, err)
+	l := append(labelValues, name)
 
-		if err != nil && err.Error() != "EOF" {
-			log.Errorln(col.Name() + ": " + err.Error())
+	success := 0
+	if err == nil {
+		success = 1
+	}
+	ch <- prometheus.MustNewConstMetric(collectorSuccessDesc, prometheus.GaugeValue, float64(success), l...)
+	ch <- prometheus.MustNewConstMetric(collectorFailuresDesc, prometheus.CounterValue, float64(status.CommandFailures), append(l, failureCommand)...)
+	ch <- prometheus.MustNewConstMetric(collectorFailuresDesc, prometheus.CounterValue, float64(status.ParseFailures), append(l, failureParse)...)
+
+	if !status.LastError.IsZero() {
+		ch <- prometheus.MustNewConstMetric(collectorLastErrorDesc, prometheus.GaugeValue, float64(status.LastError.UnixNano())/1e9, l...)
+	}
+}
+
+// openReplay serves the recorded transcript of device instead of connecting to it
+func (c *ciscoCollector) openReplay(device *connector.Device) (*transcript.Replay, error) {
+	sanitizer, err := connector.NewOutputSanitizer(c.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return transcript.OpenReplay(transcript.FileName(c.cfg.Transcripts.Directory, device.Address()), c.cfg.Transcripts.Timing, sanitizer)
+}
+
+// acquireSession waits for a free SSH session slot. The returned func releases the slot.
+func (c *ciscoCollector) acquireSession(ctx context.Context, device *connector.Device, ch chan<- prometheus.Metric, l []string) (func(), bool) {
+	release, wait, err := limiter.acquire(ctx, device)
+	ch <- prometheus.MustNewConstMetric(queueWaitDesc, prometheus.GaugeValue, wait.Seconds(), l...)
+
+	queued, rejected := limiter.stats(device)
+	ch <- prometheus.MustNewConstMetric(sessionsQueuedDesc, prometheus.CounterValue, queued, l...)
+	ch <- prometheus.MustNewConstMetric(sessionsRejectedDesc, prometheus.CounterValue, rejected, l...)
+
+	if err != nil {
+		log.Errorf("%s: no free SSH session slot: %s", device.Host, err)
+		ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 0, append(l, "session_limit")...)
+		return nil, false
+	}
+
+	return release, true
+}
+
+// connect opens the SSH connection to device, failures are reported by metrics
+func (c *ciscoCollector) connect(ctx context.Context, device *connector.Device, ch chan<- prometheus.Metric, l []string) (*connector.SSHConnection, bool) {
+	conn, err := connector.NewSSSHConnection(ctx, device, c.cfg)
+	if err != nil {
+		reason := connector.FailureReason(err)
+		if reason != connector.ReasonCircuitOpen {
+			log.Errorf("%s: %s", device.Host, err)
 		}
 
-		ch <- prometheus.MustNewConstMetric(scrapeCollectorDurationDesc, prometheus.GaugeValue, time.Since(ct).Seconds(), append(l, col.Name())...)
+		var ce *connector.ConnectError
+		if errors.As(err, &ce) {
+			c.collectTimings(ch, l, ce.Timings)
+		}
+		ch <- prometheus.MustNewConstMetric(connectFailureDesc, prometheus.GaugeValue, 1, append(l, reason)...)
+		ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 0, append(l, reason)...)
+		return nil, false
+	}
+	c.collectTimings(ch, l, conn.Timings())
+	c.collectLoginInfo(ch, l, conn.LoginInfo())
+
+	return conn, true
+}
+
+func (c *ciscoCollector) collectTimings(ch chan<- prometheus.Metric, labelValues []string, timings []connector.PhaseTiming) {
+	for _, t := range timings {
+		ch <- prometheus.MustNewConstMetric(connectPhaseDurationDesc, prometheus.GaugeValue, t.Duration.Seconds(), append(labelValues, t.Phase)...)
+	}
+}
+
+func (c *ciscoCollector) collectLoginInfo(ch chan<- prometheus.Metric, labelValues []string, info connector.LoginInfo) {
+	ch <- prometheus.MustNewConstMetric(loginBannerDesc, prometheus.GaugeValue, 1, append(labelValues, info.BannerSummary(), info.MOTDSummary(), info.Hash())...)
+
+	if t, ok := info.LastLogin(); ok {
+		ch <- prometheus.MustNewConstMetric(lastLoginDesc, prometheus.GaugeValue, float64(t.Unix()), append(labelValues, info.LastLoginSource)...)
+	} else if info.LastLoginTime != "" {
+		log.Debugf("%s: unknown format of the last login time %q", labelValues[0], info.LastLoginTime)
 	}
 }
@@ -0,0 +1,59 @@
+// fake_device starts an emulated device to demo the exporter without real hardware
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/shenjler/ssh_ping_exporter/fakedevice"
+)
+
+var (
+	listenAddress = flag.String("listen-address", "127.0.0.1:2222", "Address the SSH server listens on")
+	profile       = flag.String("profile", "linux", "Device to emulate: linux, cisco or huawei")
+	hostname      = flag.String("hostname", "fake-device", "Host name shown in prompt and outputs")
+	username      = flag.String("username", "", "Username required to log in (empty accepts any credentials)")
+	password      = flag.String("password", "", "Password required to log in")
+	noPager       = flag.Bool("no-pager", false, "Disable the pager")
+	noPTY         = flag.Bool("no-pty", false, "Refuse PTY requests")
+)
+
+func main() {
+	flag.Parse()
+
+	var device fakedevice.Device
+	switch *profile {
+	case "linux":
+		device = fakedevice.Linux(*hostname)
+	case "cisco":
+		device = fakedevice.Cisco(*hostname)
+	case "huawei":
+		device = fakedevice.Huawei(*hostname)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown profile: %s\n", *profile)
+		os.Exit(1)
+	}
+
+	device.Username = *username
+	device.Password = *password
+	device.RefusePTY = *noPTY
+	if *noPager {
+		device.PagerLines = 0
+	}
+
+	s, err := fakedevice.Start(*listenAddress, device)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s device %s listening on %s\n", *profile, *hostname, s.Addr())
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	<-sig
+
+	s.Close()
+}
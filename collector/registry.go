@@ -0,0 +1,69 @@
+package collector
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/shenjler/ssh_ping_exporter/config"
+)
+
+// Registration describes a built-in collector. Collector packages register themselves in their init function.
+type Registration struct {
+	// Name is the key of the collector in features, flags and the collectors section of the config
+	Name string
+	// Help describes the collector
+	Help string
+	// DefaultEnabled enables the collector unless it is disabled in the features
+	DefaultEnabled bool
+	// NewConfig returns the config of the collector with its defaults, nil if the collector can not be configured
+	NewConfig func() interface{}
+	// New creates the collector from the value returned by NewConfig
+	New func(cfg interface{}) (RPCCollector, error)
+}
+
+var registry = make(map[string]*Registration)
+
+// Register registers a collector, names have to be unique
+func Register(r *Registration) {
+	if _, found := registry[r.Name]; found {
+		panic("collector registered twice: " + r.Name)
+	}
+
+	registry[r.Name] = r
+}
+
+// Lookup returns the registration of the collector name
+func Lookup(name string) (*Registration, bool) {
+	r, found := registry[name]
+
+	return r, found
+}
+
+// Registered returns all registered collectors ordered by name
+func Registered() []*Registration {
+	result := make([]*Registration, 0, len(registry))
+	for _, r := range registry {
+		result = append(result, r)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+// Create creates the collector, its config is decoded from section on top of the defaults
+func (r *Registration) Create(section *config.RawConfig) (RPCCollector, error) {
+	var cfg interface{}
+	if r.NewConfig != nil {
+		cfg = r.NewConfig()
+		err := section.Decode(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid config of collector %s: %w", r.Name, err)
+		}
+	} else if section != nil {
+		return nil, fmt.Errorf("collector %s has no config", r.Name)
+	}
+
+	return r.New(cfg)
+}
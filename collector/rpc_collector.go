@@ -14,8 +14,6 @@ type RPCCollector interface {
 	// Describe describes the metrics
 	Describe(ch chan<- *prometheus.Desc)
 
-	// Collect collects metrics from Cisco
-	Collect(client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error
-
-	CollectByDest(client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string, dest string) error
+	// Collect collects metrics from Cisco. Commands are aborted when the context of sc is done.
+	Collect(sc *ScrapeContext, client *rpc.Client, ch chan<- prometheus.Metric) error
 }
@@ -0,0 +1,71 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+
+	"github.com/shenjler/ssh_ping_exporter/config"
+)
+
+// ScrapeContext carries everything about the scrape of one device a collector may need besides the client
+type ScrapeContext struct {
+	// Context is done when the scrape is aborted, commands are aborted with it
+	Context context.Context
+	// Params are the URL parameters of the scrape request
+	Params url.Values
+	// Probe are the probe settings of the scrape
+	Probe ProbeSettings
+	// Device is the device scraped
+	Device DeviceInfo
+	// Logger logs messages of the collector for the device
+	Logger *Logger
+}
+
+// ProbeSettings control active probes run from the device
+type ProbeSettings struct {
+	// Dest is the destination to ping
+	Dest string
+}
+
+// DeviceInfo describes the device scraped
+type DeviceInfo struct {
+	// Host is the target as configured
+	Host string
+	// Config is the config of the device, nil if the device is not configured explicitly
+	Config *config.DeviceConfig
+	// LabelValues are the values of the labels every metric of the device starts with, the target first
+	LabelValues []string
+}
+
+// LabelValues returns the label values of the device followed by values, the label values of the device are not modified
+func (sc *ScrapeContext) LabelValues(values ...string) []string {
+	l := make([]string, 0, len(sc.Device.LabelValues)+len(values))
+	l = append(l, sc.Device.LabelValues...)
+
+	return append(l, values...)
+}
+
+// Logger logs messages prefixed with the target
+type Logger struct {
+	target string
+	debug  bool
+}
+
+// NewLogger creates a logger for target, debug enables Debugf
+func NewLogger(target string, debug bool) *Logger {
+	return &Logger{target: target, debug: debug}
+}
+
+// Printf logs a message
+func (l *Logger) Printf(format string, args ...interface{}) {
+	log.Printf("%s: %s\n", l.target, fmt.Sprintf(format, args...))
+}
+
+// Debugf logs a message in debug mode only
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	if l.debug {
+		l.Printf(format, args...)
+	}
+}
@@ -1,22 +1,41 @@
 package main
 
 import (
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/shenjler/ssh_ping_exporter/collector"
 	"github.com/shenjler/ssh_ping_exporter/config"
 	"github.com/shenjler/ssh_ping_exporter/connector"
-	"github.com/shenjler/ssh_ping_exporter/icmp"
+	"github.com/shenjler/ssh_ping_exporter/custom"
+
+	// built-in collectors register themselves
+	_ "github.com/shenjler/ssh_ping_exporter/bgp"
+	_ "github.com/shenjler/ssh_ping_exporter/environment"
+	_ "github.com/shenjler/ssh_ping_exporter/facts"
+	_ "github.com/shenjler/ssh_ping_exporter/icmp"
+	_ "github.com/shenjler/ssh_ping_exporter/interfaces"
+	_ "github.com/shenjler/ssh_ping_exporter/optics"
 )
 
+// availableCollector is a collector created for the config, enabled per device by its name in the features
+type availableCollector struct {
+	name           string
+	defaultEnabled bool
+	collector      collector.RPCCollector
+}
+
 type collectors struct {
 	collectors map[string]collector.RPCCollector
-	devices    map[string][]collector.RPCCollector
+	devices    map[string][]*availableCollector
+	available  []*availableCollector
 	cfg        *config.Config
 }
 
-func collectorsForDevices(devices []*connector.Device, cfg *config.Config) *collectors {
+func collectorsForDevices(devices []*connector.Device, available []*availableCollector, cfg *config.Config) *collectors {
 	c := &collectors{
 		collectors: make(map[string]collector.RPCCollector),
-		devices:    make(map[string][]collector.RPCCollector),
+		devices:    make(map[string][]*availableCollector),
+		available:  available,
 		cfg:        cfg,
 	}
 
@@ -30,29 +49,108 @@ func collectorsForDevices(devices []*connector.Device, cfg *config.Config) *coll
 func (c *collectors) initCollectorsForDevice(device *connector.Device) {
 	f := c.cfg.FeaturesForDevice(device.Host)
 
-	c.devices[device.Host] = make([]collector.RPCCollector, 0)
-	c.addCollectorIfEnabledForDevice(device, "icmp", f.Icmp, icmp.NewCollector)
+	c.devices[device.Host] = make([]*availableCollector, 0)
+	for _, a := range c.available {
+		if f.Enabled(a.name, a.defaultEnabled) {
+			c.addCollectorForDevice(device, a)
+		}
+	}
+}
+
+// collectorsForConfig creates the registered collectors with their config and the custom collectors declared in cfg
+func collectorsForConfig(cfg *config.Config) ([]*availableCollector, error) {
+	for name := range cfg.Collectors {
+		if _, found := collector.Lookup(name); !found {
+			return nil, errors.Errorf("config for unknown collector %q", name)
+		}
+	}
+
+	var cols []*availableCollector
+	for _, r := range collector.Registered() {
+		col, err := r.Create(cfg.Collectors[r.Name])
+		if err != nil {
+			return nil, err
+		}
+		cols = append(cols, &availableCollector{name: r.Name, defaultEnabled: r.DefaultEnabled, collector: col})
+	}
+
+	names := make(map[string]bool)
+	for _, cc := range cfg.CustomCollectors {
+		if _, found := collector.Lookup(cc.Name); found || names[cc.Name] {
+			return nil, errors.Errorf("duplicate collector name %q", cc.Name)
+		}
+		names[cc.Name] = true
+
+		col, err := custom.NewCollector(cc)
+		if err != nil {
+			return nil, err
+		}
+		// custom collectors are enabled unless disabled explicitly
+		cols = append(cols, &availableCollector{name: cc.Name, defaultEnabled: true, collector: col})
+	}
 
-	// c.addCollectorIfEnabledForDevice(device, "bgp", f.BGP, bgp.NewCollector)
-	// c.addCollectorIfEnabledForDevice(device, "environment", f.Environment, environment.NewCollector)
-	// c.addCollectorIfEnabledForDevice(device, "facts", f.Facts, facts.NewCollector)
-	// c.addCollectorIfEnabledForDevice(device, "interfaces", f.Interfaces, interfaces.NewCollector)
-	// c.addCollectorIfEnabledForDevice(device, "optics", f.Optics, optics.NewCollector)
+	err := checkMetricNames(cols)
+	if err != nil {
+		return nil, err
+	}
 
+	return cols, nil
 }
 
-func (c *collectors) addCollectorIfEnabledForDevice(device *connector.Device, key string, enabled *bool, newCollector func() collector.RPCCollector) {
-	if !*enabled {
-		return
+// checkMetricNames returns an error if a metric name is used by more than one collector or clashes with a metric of the exporter itself.
+// The collectors are registered in a registry only used for this check.
+func checkMetricNames(cols []*availableCollector) error {
+	owners := []*describer{{owner: "the exporter", describe: describeExporterMetrics}}
+	registry := prometheus.NewPedanticRegistry()
+	err := registry.Register(owners[0])
+	if err != nil {
+		return errors.Wrap(err, "invalid metrics of the exporter")
 	}
 
-	col, found := c.collectors[key]
-	if !found {
-		col = newCollector()
-		c.collectors[key] = col
+	for _, a := range cols {
+		d := &describer{owner: "collector " + a.name, describe: a.collector.Describe}
+		err := registry.Register(d)
+		if err != nil {
+			var are prometheus.AlreadyRegisteredError
+			if errors.As(err, &are) {
+				return errors.Errorf("%s exports the same metrics as %s", d.owner, are.ExistingCollector.(*describer).owner)
+			}
+			return errors.Wrapf(err, "%s clashes with %s", d.owner, clashingOwner(owners, d))
+		}
+		owners = append(owners, d)
 	}
 
-	c.devices[device.Host] = append(c.devices[device.Host], col)
+	return nil
+}
+
+// clashingOwner returns which of owners exports a metric of d, d itself if none does
+func clashingOwner(owners []*describer, d *describer) string {
+	for _, o := range owners {
+		registry := prometheus.NewPedanticRegistry()
+		if registry.Register(o) == nil && registry.Register(d) != nil {
+			return o.owner
+		}
+	}
+
+	return "itself"
+}
+
+// describer is a collector only describing metrics, it is registered to check their names
+type describer struct {
+	owner    string
+	describe func(ch chan<- *prometheus.Desc)
+}
+
+func (d *describer) Describe(ch chan<- *prometheus.Desc) {
+	d.describe(ch)
+}
+
+func (d *describer) Collect(ch chan<- prometheus.Metric) {
+}
+
+func (c *collectors) addCollectorForDevice(device *connector.Device, a *availableCollector) {
+	c.collectors[a.name] = a.collector
+	c.devices[device.Host] = append(c.devices[device.Host], a)
 }
 
 func (c *collectors) allEnabledCollectors() []collector.RPCCollector {
@@ -67,10 +165,11 @@ func (c *collectors) allEnabledCollectors() []collector.RPCCollector {
 	return collectors
 }
 
-func (c *collectors) collectorsForDevice(device *connector.Device) []collector.RPCCollector {
+// collectorsForDevice returns the collectors enabled for device with the names they are enabled by
+func (c *collectors) collectorsForDevice(device *connector.Device) []*availableCollector {
 	cols, found := c.devices[device.Host]
 	if !found {
-		return []collector.RPCCollector{}
+		return []*availableCollector{}
 	}
 
 	return cols
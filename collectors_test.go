@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/shenjler/ssh_ping_exporter/config"
+)
+
+// customCollectorConfig declares a custom collector, the arguments are its name and the name of its metric
+const customCollectorConfig = `
+  - name: %s
+    commands:
+      default: show ntp associations
+    regex: '^(?P<peer>\S+)\s+(?P<stratum>\d+)'
+    metrics:
+      - name: %s
+        value: stratum
+        labels: [peer]
+`
+
+func TestCollectorsForConfigMetricNames(t *testing.T) {
+	tests := []struct {
+		name       string
+		collectors [][2]string
+		// err are the parts of the expected error
+		err []string
+	}{
+		{
+			name:       "distinct",
+			collectors: [][2]string{{"ntp", "pccw_ntp_peer_stratum"}, {"ntp2", "pccw_ntp2_peer_stratum"}},
+		},
+		{
+			name:       "duplicate custom metric",
+			collectors: [][2]string{{"ntp", "pccw_ntp_peer_stratum"}, {"ntp2", "pccw_ntp_peer_stratum"}},
+			err:        []string{"collector ntp2 clashes with collector ntp", `"pccw_ntp_peer_stratum"`},
+		},
+		{
+			name:       "metric of the exporter",
+			collectors: [][2]string{{"ntp", "pccw_up"}},
+			err:        []string{"collector ntp clashes with the exporter", `"pccw_up"`},
+		},
+		{
+			name:       "metric of a built-in collector",
+			collectors: [][2]string{{"ntp", "cisco_bgp_session_up"}},
+			err:        []string{"collector ntp clashes with collector bgp", `"cisco_bgp_session_up"`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			yml := "custom_collectors:"
+			for _, c := range test.collectors {
+				yml += fmt.Sprintf(customCollectorConfig, c[0], c[1])
+			}
+
+			c, err := config.Load(strings.NewReader(yml))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = collectorsForConfig(c)
+			if len(test.err) == 0 && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(test.err) > 0 && err == nil {
+				t.Fatalf("expected an error containing %q", test.err)
+			}
+			for _, part := range test.err {
+				if !strings.Contains(err.Error(), part) {
+					t.Errorf("expected error containing %q, got %s", part, err)
+				}
+			}
+		})
+	}
+}
+
+func TestCollectorsForConfigSameMetrics(t *testing.T) {
+	yml := "custom_collectors:"
+	for _, name := range []string{"ntp", "ntp2"} {
+		yml += fmt.Sprintf(customCollectorConfig, name, "pccw_ntp_peer_stratum") + "        help: Stratum of the NTP peer\n"
+	}
+
+	c, err := config.Load(strings.NewReader(yml))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = collectorsForConfig(c)
+	expected := "collector ntp2 exports the same metrics as collector ntp"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+}
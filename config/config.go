@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"io"
 	"io/ioutil"
 	"strings"
@@ -10,43 +11,183 @@ import (
 
 // Config represents the configuration for the exporter
 type Config struct {
-	Debug         bool            `yaml:"debug"`
-	LegacyCiphers bool            `yaml:"legacy_ciphers,omitempty"`
-	Timeout       int             `yaml:"timeout,omitempty"`
-	BatchSize     int             `yaml:"batch_size,omitempty"`
-	Username      string          `yaml:"username,omitempty"`
-	Password      string          `yaml:"Password,omitempty"`
-	KeyFile       string          `yaml:"key_file,omitempty"`
-	Devices       []*DeviceConfig `yaml:"devices,omitempty"`
-	Features      *FeatureConfig  `yaml:"features,omitempty"`
+	Debug                   bool                        `yaml:"debug"`
+	LegacyCiphers           bool                        `yaml:"legacy_ciphers,omitempty"`
+	Algorithms              *AlgorithmConfig            `yaml:"algorithms,omitempty"`
+	Timeout                 int                         `yaml:"timeout,omitempty"`
+	ScrapeTimeout           int                         `yaml:"scrape_timeout,omitempty"`
+	BatchSize               int                         `yaml:"batch_size,omitempty"`
+	MaxOutput               int                         `yaml:"max_output,omitempty"`
+	MaxConcurrency          int                         `yaml:"max_concurrency,omitempty"`
+	MaxSessions             int                         `yaml:"max_sessions,omitempty"`
+	MinLoginInterval        int                         `yaml:"min_login_interval,omitempty"`
+	Retries                 int                         `yaml:"retries,omitempty"`
+	RetryBackoff            int                         `yaml:"retry_backoff_ms,omitempty"`
+	CircuitBreakerThreshold int                         `yaml:"circuit_breaker_threshold,omitempty"`
+	CircuitBreakerCooldown  int                         `yaml:"circuit_breaker_cooldown,omitempty"`
+	KeepaliveInterval       int                         `yaml:"keepalive_interval,omitempty"`
+	KeepaliveMaxMissed      int                         `yaml:"keepalive_max_missed,omitempty"`
+	IdentifyCacheTTL        int                         `yaml:"identify_cache_ttl,omitempty"`
+	Username                string                      `yaml:"username,omitempty"`
+	Password                string                      `yaml:"Password,omitempty"`
+	KeyFile                 string                      `yaml:"key_file,omitempty"`
+	Proxy                   string                      `yaml:"proxy,omitempty"`
+	PTY                     string                      `yaml:"pty,omitempty"`
+	Privileged              bool                        `yaml:"privileged,omitempty"`
+	EnablePassword          string                      `yaml:"enable_password,omitempty"`
+	Transcripts             *TranscriptConfig           `yaml:"transcripts,omitempty"`
+	Audit                   *AuditConfig                `yaml:"audit,omitempty"`
+	Sanitizers              map[string]*SanitizerConfig `yaml:"sanitizers,omitempty"`
+	CommandAllowlist        map[string][]string         `yaml:"command_allowlist,omitempty"`
+	Collectors              map[string]*RawConfig       `yaml:"collectors,omitempty"`
+	CustomCollectors        []*CustomCollectorConfig    `yaml:"custom_collectors,omitempty"`
+	Templates               []*TemplateConfig           `yaml:"templates,omitempty"`
+	Devices                 []*DeviceConfig             `yaml:"devices,omitempty"`
+	Features                FeatureConfig               `yaml:"features,omitempty"`
 }
 
 // DeviceConfig is the config representation of 1 device
 type DeviceConfig struct {
-	Host          string         `yaml:"host"`
-	Username      *string        `yaml:"username,omitempty"`
-	Password      *string        `yaml:"password,omitempty"`
-	KeyFile       *string        `yaml:"key_file,omitempty"`
-	LegacyCiphers *bool          `yaml:"legacy_ciphers,omitempty"`
-	Timeout       *int           `yaml:"timeout,omitempty"`
-	BatchSize     *int           `yaml:"batch_size,omitempty"`
-	Features      *FeatureConfig `yaml:"features,omitempty"`
-}
-
-// FeatureConfig is the list of collectors enabled or disabled
-type FeatureConfig struct {
-	Icmp        *bool `yaml:"icmp,omitempty"`
-	BGP         *bool `yaml:"bgp,omitempty"`
-	Environment *bool `yaml:"environment,omitempty"`
-	Facts       *bool `yaml:"facts,omitempty"`
-	Interfaces  *bool `yaml:"interfaces,omitempty"`
-	Optics      *bool `yaml:"optics,omitempty"`
+	Host              string           `yaml:"host"`
+	OS                *string          `yaml:"os,omitempty"`
+	Username          *string          `yaml:"username,omitempty"`
+	Password          *string          `yaml:"password,omitempty"`
+	KeyFile           *string          `yaml:"key_file,omitempty"`
+	Proxy             *string          `yaml:"proxy,omitempty"`
+	PTY               *string          `yaml:"pty,omitempty"`
+	Privileged        *bool            `yaml:"privileged,omitempty"`
+	EnablePassword    *string          `yaml:"enable_password,omitempty"`
+	LegacyCiphers     *bool            `yaml:"legacy_ciphers,omitempty"`
+	Algorithms        *AlgorithmConfig `yaml:"algorithms,omitempty"`
+	Timeout           *int             `yaml:"timeout,omitempty"`
+	BatchSize         *int             `yaml:"batch_size,omitempty"`
+	MaxOutput         *int             `yaml:"max_output,omitempty"`
+	MaxSessions       *int             `yaml:"max_sessions,omitempty"`
+	MinLoginInterval  *int             `yaml:"min_login_interval,omitempty"`
+	Retries           *int             `yaml:"retries,omitempty"`
+	RetryBackoff      *int             `yaml:"retry_backoff_ms,omitempty"`
+	KeepaliveInterval *int             `yaml:"keepalive_interval,omitempty"`
+	Features          FeatureConfig    `yaml:"features,omitempty"`
+}
+
+// AlgorithmConfig selects the algorithms offered in the SSH handshake
+type AlgorithmConfig struct {
+	Preset            string   `yaml:"preset,omitempty"`
+	Ciphers           []string `yaml:"ciphers,omitempty"`
+	KeyExchanges      []string `yaml:"key_exchanges,omitempty"`
+	MACs              []string `yaml:"macs,omitempty"`
+	HostKeyAlgorithms []string `yaml:"host_key_algorithms,omitempty"`
+}
+
+// TranscriptConfig controls recording and replaying of the commands sent to devices
+type TranscriptConfig struct {
+	Mode      string `yaml:"mode,omitempty"`
+	Directory string `yaml:"directory,omitempty"`
+	Timing    bool   `yaml:"timing,omitempty"`
+}
+
+// Recording reports whether transcripts are recorded
+func (t *TranscriptConfig) Recording() bool {
+	return t != nil && t.Mode == "record"
+}
+
+// Replaying reports whether devices are replaced by recorded transcripts
+func (t *TranscriptConfig) Replaying() bool {
+	return t != nil && t.Mode == "replay"
+}
+
+// AuditConfig controls the audit log of the commands sent to devices
+type AuditConfig struct {
+	File          string `yaml:"file,omitempty"`
+	MaxSizeMB     int    `yaml:"max_size_mb,omitempty"`
+	MaxBackups    int    `yaml:"max_backups,omitempty"`
+	Syslog        bool   `yaml:"syslog,omitempty"`
+	SyslogTag     string `yaml:"syslog_tag,omitempty"`
+	CaptureOutput bool   `yaml:"capture_output,omitempty"`
+}
+
+// SanitizerConfig controls how the output of commands is cleaned up. Unset flags default to true.
+type SanitizerConfig struct {
+	StripANSI      *bool    `yaml:"strip_ansi,omitempty"`
+	StripBackspace *bool    `yaml:"strip_backspace,omitempty"`
+	StripEcho      *bool    `yaml:"strip_echo,omitempty"`
+	StripPrompt    *bool    `yaml:"strip_prompt,omitempty"`
+	DropLines      []string `yaml:"drop_lines,omitempty"`
+}
+
+// FeatureConfig enables or disables collectors by name, built-in and custom ones alike
+type FeatureConfig map[string]bool
+
+// Enabled reports whether the collector name is enabled, def applies if the collector is not listed
+func (f FeatureConfig) Enabled(name string, def bool) bool {
+	enabled, found := f[name]
+	if !found {
+		return def
+	}
+
+	return enabled
+}
+
+// RawConfig keeps a config section whose schema is only known to its consumer, e.g. the config of a collector
+type RawConfig struct {
+	unmarshal func(interface{}) error
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler, decoding is deferred to Decode
+func (r *RawConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	r.unmarshal = unmarshal
+
+	return nil
+}
+
+// Decode decodes the section into v, v is left untouched if there is no section
+func (r *RawConfig) Decode(v interface{}) error {
+	if r == nil || r.unmarshal == nil {
+		return nil
+	}
+
+	return r.unmarshal(v)
+}
+
+// CustomCollectorConfig declares a collector running a command and turning the matches of a regular expression into metrics
+type CustomCollectorConfig struct {
+	Name string `yaml:"name"`
+	// Commands per OS type, "default" is used for other OSes
+	Commands map[string]string `yaml:"commands"`
+	// Regex with named groups, matched against each line (match: line) or the whole output (match: block)
+	Regex string `yaml:"regex,omitempty"`
+	Match string `yaml:"match,omitempty"`
+	// Template is a TextFSM template file used instead of Regex, metrics refer to its values
+	Template string                `yaml:"template,omitempty"`
+	Metrics  []*CustomMetricConfig `yaml:"metrics"`
+}
+
+// CustomMetricConfig declares a metric of a custom collector
+type CustomMetricConfig struct {
+	Name string `yaml:"name"`
+	Help string `yaml:"help,omitempty"`
+	// Type is gauge (default) or counter
+	Type string `yaml:"type,omitempty"`
+	// Value is the group holding the value, ValueMap translates non numeric values (e.g. Established: 1)
+	Value    string             `yaml:"value"`
+	ValueMap map[string]float64 `yaml:"value_map,omitempty"`
+	// Labels are the groups used as labels
+	Labels []string `yaml:"labels,omitempty"`
+}
+
+// TemplateConfig replaces the parser of a built-in collector (bgp, environment or interfaces) for an OS by a TextFSM template
+type TemplateConfig struct {
+	OS        string `yaml:"os"`
+	Collector string `yaml:"collector"`
+	// Command defaults to the command of the built-in collector
+	Command string `yaml:"command,omitempty"`
+	File    string `yaml:"file"`
 }
 
 // New creates a new config
 func New() *Config {
 	c := &Config{
-		Features: &FeatureConfig{},
+		Features: make(FeatureConfig),
 	}
 	c.setDefaultValues()
 
@@ -66,27 +207,18 @@ func Load(reader io.Reader) (*Config, error) {
 		return nil, err
 	}
 
+	if c.Transcripts != nil && !c.Transcripts.Recording() && !c.Transcripts.Replaying() && c.Transcripts.Mode != "" {
+		return nil, errors.New("invalid transcript mode: " + c.Transcripts.Mode)
+	}
+
 	for _, d := range c.Devices {
 		if d.Features == nil {
 			continue
 		}
-		if d.Features.Icmp == nil {
-			d.Features.Icmp = c.Features.Icmp
-		}
-		if d.Features.BGP == nil {
-			d.Features.BGP = c.Features.BGP
-		}
-		if d.Features.Environment == nil {
-			d.Features.Environment = c.Features.Environment
-		}
-		if d.Features.Facts == nil {
-			d.Features.Facts = c.Features.Facts
-		}
-		if d.Features.Interfaces == nil {
-			d.Features.Interfaces = c.Features.Interfaces
-		}
-		if d.Features.Optics == nil {
-			d.Features.Optics = c.Features.Optics
+		for name, enabled := range c.Features {
+			if _, found := d.Features[name]; !found {
+				d.Features[name] = enabled
+			}
 		}
 	}
 
@@ -98,20 +230,14 @@ func (c *Config) setDefaultValues() {
 	c.LegacyCiphers = false
 	c.Timeout = 5
 	c.BatchSize = 10000
-
-	f := c.Features
-	icmp := true
-	f.Icmp = &icmp
-	bgp := true
-	f.BGP = &bgp
-	environment := true
-	f.Environment = &environment
-	facts := true
-	f.Facts = &facts
-	interfaces := true
-	f.Interfaces = &interfaces
-	optics := true
-	f.Optics = &optics
+	c.MaxOutput = 16 << 20
+	c.Retries = 0
+	c.RetryBackoff = 500
+	c.CircuitBreakerThreshold = 0
+	c.CircuitBreakerCooldown = 60
+	c.KeepaliveInterval = 0
+	c.KeepaliveMaxMissed = 3
+	c.IdentifyCacheTTL = 3600
 }
 
 // DevicesFromTargets creates devices configs from targets list
@@ -127,7 +253,7 @@ func (c *Config) DevicesFromTargets(sshHosts string) {
 }
 
 // FeaturesForDevice gets the feature set configured for a device
-func (c *Config) FeaturesForDevice(host string) *FeatureConfig {
+func (c *Config) FeaturesForDevice(host string) FeatureConfig {
 	d := c.findDeviceConfig(host)
 
 	if d != nil && d.Features != nil {
@@ -0,0 +1,163 @@
+package connector
+
+import (
+	"github.com/pkg/errors"
+	"github.com/shenjler/ssh_ping_exporter/config"
+	"golang.org/x/crypto/ssh"
+)
+
+// algorithmSet lists the algorithms offered in the SSH handshake, empty lists leave the choice to the SSH library
+type algorithmSet struct {
+	ciphers           []string
+	keyExchanges      []string
+	macs              []string
+	hostKeyAlgorithms []string
+}
+
+var modernAlgorithms = algorithmSet{
+	ciphers: []string{
+		"aes128-gcm@openssh.com", "chacha20-poly1305@openssh.com",
+		"aes128-ctr", "aes192-ctr", "aes256-ctr",
+	},
+	keyExchanges: []string{
+		"curve25519-sha256@libssh.org",
+		"ecdh-sha2-nistp256", "ecdh-sha2-nistp384", "ecdh-sha2-nistp521",
+		"diffie-hellman-group-exchange-sha256",
+	},
+	macs: []string{
+		"hmac-sha2-256-etm@openssh.com", "hmac-sha2-256",
+	},
+	// RSA host keys can only be verified with ssh-rsa signatures by the SSH library in use
+	hostKeyAlgorithms: []string{
+		ssh.KeyAlgoED25519,
+		ssh.KeyAlgoECDSA256, ssh.KeyAlgoECDSA384, ssh.KeyAlgoECDSA521,
+		ssh.KeyAlgoRSA,
+	},
+}
+
+// legacyAlgorithms additionally allows what older IOS and VRP images still require
+var legacyAlgorithms = algorithmSet{
+	ciphers: []string{
+		"aes128-gcm@openssh.com", "chacha20-poly1305@openssh.com",
+		"aes128-ctr", "aes192-ctr", "aes256-ctr",
+		"aes128-cbc", "3des-cbc",
+	},
+	keyExchanges: []string{
+		"curve25519-sha256@libssh.org",
+		"ecdh-sha2-nistp256", "ecdh-sha2-nistp384", "ecdh-sha2-nistp521",
+		"diffie-hellman-group-exchange-sha256",
+		"diffie-hellman-group14-sha1", "diffie-hellman-group-exchange-sha1", "diffie-hellman-group1-sha1",
+	},
+	macs: []string{
+		"hmac-sha2-256-etm@openssh.com", "hmac-sha2-256",
+		"hmac-sha1", "hmac-sha1-96",
+	},
+	hostKeyAlgorithms: []string{
+		ssh.KeyAlgoED25519,
+		ssh.KeyAlgoECDSA256, ssh.KeyAlgoECDSA384, ssh.KeyAlgoECDSA521,
+		ssh.KeyAlgoRSA, ssh.KeyAlgoDSA,
+	},
+}
+
+var algorithmPresets = map[string]algorithmSet{
+	"modern": modernAlgorithms,
+	"legacy": legacyAlgorithms,
+}
+
+// supportedAlgorithms contains every algorithm the SSH library in use is able to negotiate
+var supportedAlgorithms = algorithmSet{
+	ciphers:      append([]string{"arcfour256", "arcfour128", "arcfour"}, legacyAlgorithms.ciphers...),
+	keyExchanges: legacyAlgorithms.keyExchanges,
+	macs:         legacyAlgorithms.macs,
+	hostKeyAlgorithms: append([]string{
+		ssh.CertAlgoRSAv01, ssh.CertAlgoDSAv01, ssh.CertAlgoECDSA256v01,
+		ssh.CertAlgoECDSA384v01, ssh.CertAlgoECDSA521v01, ssh.CertAlgoED25519v01,
+	}, legacyAlgorithms.hostKeyAlgorithms...),
+}
+
+// algorithmsForDevice resolves the algorithms of a device. Lists of the device take precedence over global lists which take precedence over the preset.
+func algorithmsForDevice(deviceConfig *config.DeviceConfig, cfg *config.Config) (algorithmSet, error) {
+	global := cfg.Algorithms
+	if global == nil {
+		global = &config.AlgorithmConfig{}
+	}
+	device := deviceConfig.Algorithms
+	if device == nil {
+		device = &config.AlgorithmConfig{}
+	}
+
+	preset := global.Preset
+	if device.Preset != "" {
+		preset = device.Preset
+	}
+
+	set := algorithmSet{}
+	if preset != "" {
+		var found bool
+		set, found = algorithmPresets[preset]
+		if !found {
+			return algorithmSet{}, errors.Errorf("unknown algorithm preset %q", preset)
+		}
+	}
+
+	set.ciphers = firstNonEmpty(device.Ciphers, global.Ciphers, set.ciphers)
+	set.keyExchanges = firstNonEmpty(device.KeyExchanges, global.KeyExchanges, set.keyExchanges)
+	set.macs = firstNonEmpty(device.MACs, global.MACs, set.macs)
+	set.hostKeyAlgorithms = firstNonEmpty(device.HostKeyAlgorithms, global.HostKeyAlgorithms, set.hostKeyAlgorithms)
+
+	checks := []struct {
+		kind       string
+		configured []string
+		supported  []string
+	}{
+		{"cipher", set.ciphers, supportedAlgorithms.ciphers},
+		{"key exchange", set.keyExchanges, supportedAlgorithms.keyExchanges},
+		{"MAC", set.macs, supportedAlgorithms.macs},
+		{"host key algorithm", set.hostKeyAlgorithms, supportedAlgorithms.hostKeyAlgorithms},
+	}
+	for _, c := range checks {
+		for _, a := range c.configured {
+			if !contains(c.supported, a) {
+				return algorithmSet{}, errors.Errorf("unsupported %s %q", c.kind, a)
+			}
+		}
+	}
+
+	return set, nil
+}
+
+// apply sets the algorithms on the client config
+func (s algorithmSet) apply(sshConfig *ssh.ClientConfig) {
+	if len(s.ciphers) > 0 {
+		sshConfig.Ciphers = s.ciphers
+	}
+	if len(s.keyExchanges) > 0 {
+		sshConfig.KeyExchanges = s.keyExchanges
+	}
+	if len(s.macs) > 0 {
+		sshConfig.MACs = s.macs
+	}
+	if len(s.hostKeyAlgorithms) > 0 {
+		sshConfig.HostKeyAlgorithms = s.hostKeyAlgorithms
+	}
+}
+
+func firstNonEmpty(lists ...[]string) []string {
+	for _, l := range lists {
+		if len(l) > 0 {
+			return l
+		}
+	}
+
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,183 @@
+package connector
+
+import (
+	"context"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// BatchTransport is implemented by transports able to send several commands in one exchange
+type BatchTransport interface {
+	Transport
+
+	// RunCommands runs cmds back-to-back and returns the output of each command
+	RunCommands(ctx context.Context, cmds []string) ([]string, error)
+}
+
+// RawBatchTransport is implemented by transports able to return the outputs of a batch before they are sanitized
+type RawBatchTransport interface {
+	RawTransport
+
+	// RunCommandsRaw runs cmds back-to-back and returns the output of each command as sent by the device
+	RunCommandsRaw(ctx context.Context, cmds []string) ([]string, error)
+}
+
+// RunCommands sends cmds at once and splits the output at the prompts preceding the echo of the next command.
+// Devices which did not echo the last command, e.g. without PTY, get the commands one after another.
+func (c *SSHConnection) RunCommands(ctx context.Context, cmds []string) ([]string, error) {
+	if !c.batching(cmds) {
+		return c.runSequentially(ctx, cmds)
+	}
+
+	parts, err := c.RunCommandsRaw(ctx, cmds)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, len(parts))
+	for i, part := range parts {
+		result[i] = c.sanitizer.Clean(part, cmds[i])
+	}
+
+	return result, nil
+}
+
+// RunCommandsRaw is RunCommands without sanitizing the outputs, they are cleaned by Sanitize
+func (c *SSHConnection) RunCommandsRaw(ctx context.Context, cmds []string) ([]string, error) {
+	if !c.batching(cmds) {
+		return c.runSequentiallyRaw(ctx, cmds)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if !c.Alive() {
+		return nil, ErrSessionDead
+	}
+
+	_, err := io.WriteString(c.stdin, strings.Join(cmds, "\n")+"\n")
+	if err != nil {
+		return nil, err
+	}
+
+	s := newBatchSplitter(cmds, c.prompt)
+	for !s.complete() {
+		out, err := c.output.readUntil(ctx, c.prompt, c.clientConfig.Timeout)
+		if err == nil && s.size+len(out) > c.maxOutput {
+			err = errors.Wrapf(ErrOutputLimitExceeded, "more than %d bytes for %d commands", c.maxOutput, len(cmds))
+		}
+		if err != nil {
+			if !c.Alive() {
+				return nil, ErrSessionDead
+			}
+			// the remaining output would be mistaken for the output of the next command
+			c.Close()
+			return nil, err
+		}
+		s.add(out)
+	}
+
+	parts := s.result()
+	for i, part := range parts {
+		if inConfigMode(part) {
+			log.Printf("%s: %s after %q", c.Host, ErrConfigMode, cmds[i])
+			c.Close()
+			return nil, ErrConfigMode
+		}
+	}
+
+	return parts, nil
+}
+
+// batching reports whether cmds are sent at once, the output can only be split if the device echoes the commands
+func (c *SSHConnection) batching(cmds []string) bool {
+	return c.pty && c.echo && len(cmds) > 1
+}
+
+func (c *SSHConnection) runSequentially(ctx context.Context, cmds []string) ([]string, error) {
+	return runEach(ctx, cmds, c.RunCommand)
+}
+
+func (c *SSHConnection) runSequentiallyRaw(ctx context.Context, cmds []string) ([]string, error) {
+	return runEach(ctx, cmds, c.RunCommandRaw)
+}
+
+func runEach(ctx context.Context, cmds []string, run func(context.Context, string) (string, error)) ([]string, error) {
+	result := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		out, err := run(ctx, cmd)
+		if err != nil {
+			return nil, errors.Wrapf(err, "command %q", cmd)
+		}
+		result[i] = out
+	}
+
+	return result, nil
+}
+
+// echoed reports whether the raw output of cmd starts with the echo of the command, blank lines before it are skipped
+func echoed(output, cmd string) bool {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(applyBackspaces(line))
+		if line != "" {
+			return line == cmd
+		}
+	}
+
+	return false
+}
+
+// batchSplitter splits the output of cmds sent back-to-back as it arrives. The output of a command ends with the prompt,
+// the echo of the next command follows on the same line.
+type batchSplitter struct {
+	cmds    []string
+	prompt  *regexp.Regexp
+	parts   []string
+	current []string
+	// pending is the last line of the output so far, the echo of the next command may still be missing
+	pending string
+	size    int
+}
+
+func newBatchSplitter(cmds []string, prompt *regexp.Regexp) *batchSplitter {
+	return &batchSplitter{cmds: cmds, prompt: prompt, parts: make([]string, 0, len(cmds))}
+}
+
+// add appends the next piece of output, only lines not yet seen are looked at
+func (s *batchSplitter) add(output string) {
+	s.size += len(output)
+
+	lines := strings.Split(s.pending+output, "\n")
+	s.pending = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		s.addLine(line)
+	}
+}
+
+func (s *batchSplitter) addLine(line string) {
+	if len(s.parts) < len(s.cmds)-1 {
+		next := s.cmds[len(s.parts)+1]
+		trimmed := strings.TrimRight(line, " \t")
+		if strings.HasSuffix(trimmed, next) && s.prompt.MatchString(strings.TrimSuffix(trimmed, next)) {
+			s.current = append(s.current, strings.TrimSuffix(trimmed, next))
+			s.parts = append(s.parts, strings.Join(s.current, "\n"))
+			s.current = []string{next}
+			return
+		}
+	}
+
+	s.current = append(s.current, line)
+}
+
+// complete reports whether the output of all commands arrived: all of them were echoed and the last prompt followed
+func (s *batchSplitter) complete() bool {
+	return len(s.parts) == len(s.cmds)-1 && s.prompt.MatchString(s.pending)
+}
+
+// result returns the output of each command with echo and prompt
+func (s *batchSplitter) result() []string {
+	return append(s.parts, strings.Join(append(s.current, s.pending), "\n"))
+}
@@ -0,0 +1,106 @@
+package connector
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/shenjler/ssh_ping_exporter/config"
+	"github.com/shenjler/ssh_ping_exporter/fakedevice"
+)
+
+var batchCommands = []string{"show version", "show bgp all summary", "show running-config | include hostname"}
+
+func TestRunCommands(t *testing.T) {
+	tests := []struct {
+		name  string
+		echo  bool
+		batch bool
+	}{
+		{name: "echo", echo: true, batch: true},
+		{name: "no echo", echo: false, batch: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dev := fakedevice.Cisco("rtr1")
+			dev.Echo = test.echo
+			dev.PagerLines = 0
+			c := connect(t, startDevice(t, dev), config.New())
+			c.SetOS("IOSXE")
+
+			_, err := c.RunCommand(context.Background(), "show running-config | include hostname")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.echo != test.batch {
+				t.Errorf("echo detected: %v, expected %v", c.echo, test.batch)
+			}
+
+			outputs, err := c.RunCommands(context.Background(), batchCommands)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := make([]string, len(batchCommands))
+			for i, cmd := range batchCommands {
+				expected[i] = expectedOutput(dev.Responses[cmd])
+			}
+			if !reflect.DeepEqual(outputs, expected) {
+				t.Errorf("got outputs:\n%q\nexpected:\n%q", outputs, expected)
+			}
+		})
+	}
+}
+
+func TestRunCommandsMaxOutput(t *testing.T) {
+	dev := fakedevice.Cisco("rtr1")
+	dev.PagerLines = 0
+	cfg := config.New()
+	// enough for every single command, not for all of them
+	cfg.MaxOutput = 2 * len(dev.Responses["show version"])
+	c := connect(t, startDevice(t, dev), cfg)
+	c.SetOS("IOSXE")
+
+	_, err := c.RunCommand(context.Background(), "show version")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = c.RunCommands(context.Background(), []string{"show version", "show version", "show version"})
+	if !errors.Is(err, ErrOutputLimitExceeded) {
+		t.Fatalf("expected %s, got %v", ErrOutputLimitExceeded, err)
+	}
+}
+
+func TestBatchSplitter(t *testing.T) {
+	cmds := []string{"show a", "show b"}
+
+	tests := []struct {
+		name   string
+		pieces []string
+	}{
+		{name: "one piece", pieces: []string{"show a\na1\nrouter#show b\nb1\nb2\nrouter#"}},
+		{name: "prompt and echo apart", pieces: []string{"show a\na1\nrouter#", "show b\nb1\nb2\nrouter#"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := newBatchSplitter(cmds, testPrompt)
+			for i, p := range test.pieces {
+				if s.complete() {
+					t.Fatalf("complete before piece %d", i)
+				}
+				s.add(p)
+			}
+			if !s.complete() {
+				t.Fatal("not complete after the last piece")
+			}
+
+			expected := []string{"show a\na1\nrouter#", "show b\nb1\nb2\nrouter#"}
+			if got := s.result(); !reflect.DeepEqual(got, expected) {
+				t.Errorf("got %q, expected %q", got, expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package connector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrCircuitOpen is returned instead of dialing a device which failed too often in a row
+var ErrCircuitOpen = errors.New("circuit open")
+
+var (
+	breakersMu sync.Mutex
+	breakers   = make(map[string]*circuitBreaker)
+)
+
+// circuitBreaker keeps track of consecutive connection failures of a device.
+// Breakers outlive connections and config reloads, they are kept per address until the device is removed from the config.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	failures  int
+	cooldown  time.Duration
+	openUntil time.Time
+	probing   bool
+}
+
+func breakerFor(address string) *circuitBreaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+
+	b, found := breakers[address]
+	if !found {
+		b = &circuitBreaker{}
+		breakers[address] = b
+	}
+
+	return b
+}
+
+// PruneBreakers drops the breakers of addresses no longer used by any of devices
+func PruneBreakers(devices []*Device) {
+	keep := make(map[string]bool, len(devices))
+	for _, d := range devices {
+		keep[d.Address()] = true
+	}
+
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+
+	for address := range breakers {
+		if !keep[address] {
+			delete(breakers, address)
+		}
+	}
+}
+
+// allow reports whether the device may be dialed. After the cool-down a single attempt is let through as probe,
+// the others are rejected until it succeeded or the cool-down of its failure has passed.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openUntil.IsZero() {
+		return true
+	}
+
+	now := time.Now()
+	if now.Before(b.openUntil) {
+		return false
+	}
+
+	// a probe which never reports back must not keep the circuit open for good
+	b.openUntil = now.Add(b.cooldown)
+	b.probing = true
+	return true
+}
+
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.openUntil = time.Time{}
+	b.probing = false
+}
+
+// failure records a failed connection and opens the circuit for cooldown once threshold is reached (0 = never)
+func (b *circuitBreaker) failure(threshold int, cooldown time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if threshold > 0 && b.failures >= threshold {
+		b.cooldown = cooldown
+		b.openUntil = time.Now().Add(cooldown)
+	}
+}
+
+// abort hands the probe to the next attempt if the attempt let through was cut short without outcome
+func (b *circuitBreaker) abort() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.probing {
+		b.probing = false
+		b.openUntil = time.Now()
+	}
+}
+
+// countsAsFailure reports whether err of a connection attempt is held against the device.
+// Attempts cut short by cancellation or the scrape deadline say nothing about the device.
+func countsAsFailure(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
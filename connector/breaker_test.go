@@ -0,0 +1,94 @@
+package connector
+
+import (
+	"testing"
+	"time"
+)
+
+const testCooldown = 50 * time.Millisecond
+
+// openBreaker returns a breaker whose cool-down has passed
+func openBreaker(t *testing.T) *circuitBreaker {
+	t.Helper()
+
+	b := &circuitBreaker{}
+	b.failure(1, testCooldown)
+	if b.allow() {
+		t.Fatal("circuit not open after reaching the threshold")
+	}
+	time.Sleep(testCooldown)
+
+	return b
+}
+
+func TestBreakerHalfOpenLetsOneProbeThrough(t *testing.T) {
+	b := openBreaker(t)
+
+	if !b.allow() {
+		t.Fatal("probe rejected after the cool-down")
+	}
+	for i := 0; i < 3; i++ {
+		if b.allow() {
+			t.Fatal("further attempt let through while the probe is running")
+		}
+	}
+
+	b.failure(1, testCooldown)
+	if b.allow() {
+		t.Fatal("attempt let through after the probe failed")
+	}
+
+	time.Sleep(testCooldown)
+	if !b.allow() {
+		t.Fatal("probe rejected after the second cool-down")
+	}
+	b.success()
+	if !b.allow() || !b.allow() {
+		t.Error("attempts rejected after the probe succeeded")
+	}
+}
+
+func TestBreakerAbortedProbe(t *testing.T) {
+	b := openBreaker(t)
+
+	if !b.allow() {
+		t.Fatal("probe rejected after the cool-down")
+	}
+	b.abort()
+	if !b.allow() {
+		t.Error("next attempt not let through as probe after the probe was aborted")
+	}
+	if b.allow() {
+		t.Error("more than one attempt let through after the probe was aborted")
+	}
+}
+
+func TestBreakerProbeWithoutOutcome(t *testing.T) {
+	b := openBreaker(t)
+
+	if !b.allow() {
+		t.Fatal("probe rejected after the cool-down")
+	}
+	time.Sleep(testCooldown)
+	if !b.allow() {
+		t.Error("circuit still open a cool-down after a probe which never reported back")
+	}
+}
+
+func TestPruneBreakers(t *testing.T) {
+	kept := &Device{Host: "lab-sw1", Port: "22"}
+	removed := &Device{Host: "lab-sw2", Port: "22"}
+	breakerFor(kept.Address())
+	breakerFor(removed.Address())
+
+	PruneBreakers([]*Device{kept})
+
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	if _, found := breakers[kept.Address()]; !found {
+		t.Error("breaker of a configured device was dropped")
+	}
+	if _, found := breakers[removed.Address()]; found {
+		t.Error("breaker of a removed device was kept")
+	}
+}
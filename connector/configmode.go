@@ -0,0 +1,34 @@
+package connector
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrConfigMode is returned when the prompt shows that the session is in configuration mode. The session is closed.
+var ErrConfigMode = errors.New("prompt indicates configuration mode, session aborted")
+
+var (
+	configModePromptRegexps = []*regexp.Regexp{
+		regexp.MustCompile(`\(conf(?:ig)?[^)]*\)#\s*$`), // IOS, IOS XE, NX-OS, EOS
+		regexp.MustCompile(`^\s*\[~?\*?[^\]\s]+\]\s*$`), // VRP and Comware system view
+	}
+	// Junos prints the edit hierarchy in the line above the prompt
+	junosEditRegexp = regexp.MustCompile(`^\s*\[edit(?: [^\]]*)?\]\s*$`)
+)
+
+// inConfigMode reports whether the prompt at the end of output belongs to a configuration mode
+func inConfigMode(output string) bool {
+	lines := strings.Split(ansiEscapeRegexp.ReplaceAllString(output, ""), "\n")
+
+	last := lines[len(lines)-1]
+	for _, r := range configModePromptRegexps {
+		if r.MatchString(last) {
+			return true
+		}
+	}
+
+	return len(lines) > 1 && junosEditRegexp.MatchString(lines[len(lines)-2])
+}
@@ -1,22 +1,28 @@
 package connector
 
 import (
-	"bufio"
+	"context"
 	"io"
 	"io/ioutil"
 	"log"
+	"math/rand"
+	"net"
 	"regexp"
-	"strings"
+	"sync"
 
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/shenjler/ssh_ping_exporter/config"
+	"github.com/shenjler/ssh_ping_exporter/driver"
 	"golang.org/x/crypto/ssh"
 )
 
-// NewSSSHConnection connects to device
-func NewSSSHConnection(device *Device, cfg *config.Config) (*SSHConnection, error) {
+// maxBackoffExponent caps the exponential growth of the time between two connection attempts
+const maxBackoffExponent = 6
+
+// NewSSSHConnection connects to device. The connection is closed as soon as ctx is done.
+func NewSSSHConnection(ctx context.Context, device *Device, cfg *config.Config) (*SSHConnection, error) {
 	deviceConfig := device.DeviceConfig
 
 	legacyCiphers := cfg.LegacyCiphers
@@ -34,6 +40,16 @@ func NewSSSHConnection(device *Device, cfg *config.Config) (*SSHConnection, erro
 		timeout = *deviceConfig.Timeout
 	}
 
+	maxOutput := cfg.MaxOutput
+	if deviceConfig.MaxOutput != nil {
+		maxOutput = *deviceConfig.MaxOutput
+	}
+
+	algorithms, err := algorithmsForDevice(deviceConfig, cfg)
+	if err != nil {
+		return nil, err
+	}
+
 	sshConfig := &ssh.ClientConfig{
 		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
 		Timeout:         time.Duration(timeout) * time.Second,
@@ -42,119 +58,398 @@ func NewSSSHConnection(device *Device, cfg *config.Config) (*SSHConnection, erro
 		sshConfig.SetDefaults()
 		sshConfig.Ciphers = append(sshConfig.Ciphers, "aes128-cbc", "3des-cbc")
 	}
+	algorithms.apply(sshConfig)
+
+	dialer, err := dialerForProxy(proxyForDevice(deviceConfig, cfg), sshConfig.Timeout)
+	if err != nil {
+		return nil, err
+	}
 
 	device.Auth(sshConfig)
 
-	c := &SSHConnection{
-		Host:         device.Host + ":" + device.Port,
-		batchSize:    batchSize,
-		clientConfig: sshConfig,
+	retries := cfg.Retries
+	if deviceConfig.Retries != nil {
+		retries = *deviceConfig.Retries
+	}
+
+	backoff := cfg.RetryBackoff
+	if deviceConfig.RetryBackoff != nil {
+		backoff = *deviceConfig.RetryBackoff
 	}
 
-	err := c.Connect()
+	ptyMode := ptyModeForDevice(deviceConfig, cfg)
+
+	sanitizer, err := NewOutputSanitizer(cfg)
 	if err != nil {
 		return nil, err
 	}
 
+	keepaliveInterval := cfg.KeepaliveInterval
+	if deviceConfig.KeepaliveInterval != nil {
+		keepaliveInterval = *deviceConfig.KeepaliveInterval
+	}
+
+	address := device.Address()
+	breaker := breakerFor(address)
+	if !breaker.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	var c *SSHConnection
+	for attempt := 0; ; attempt++ {
+		c = &SSHConnection{
+			Host:           address,
+			batchSize:      batchSize,
+			maxOutput:      maxOutput,
+			prompt:         driver.Generic.Prompt,
+			ptyMode:        ptyMode,
+			enablePassword: device.EnablePassword,
+			sanitizer:      sanitizer,
+			clientConfig:   sshConfig,
+			dialer:         dialer,
+			done:           make(chan struct{}),
+		}
+
+		err = c.Connect(ctx)
+		if err == nil || attempt >= retries || !isRetryable(ctx, err) {
+			break
+		}
+
+		log.Printf("%s: connection attempt %d failed, retrying: %s", address, attempt+1, err)
+		if waitErr := waitForRetry(ctx, time.Duration(backoff)*time.Millisecond, attempt); waitErr != nil {
+			break
+		}
+	}
+
+	if err != nil {
+		if countsAsFailure(ctx, err) {
+			breaker.failure(cfg.CircuitBreakerThreshold, time.Duration(cfg.CircuitBreakerCooldown)*time.Second)
+		} else {
+			breaker.abort()
+		}
+		return nil, err
+	}
+	breaker.success()
+
+	if keepaliveInterval > 0 {
+		go c.keepalive(time.Duration(keepaliveInterval)*time.Second, cfg.KeepaliveMaxMissed)
+	}
+
 	return c, nil
 }
 
+// isRetryable reports whether another connection attempt could succeed. Failed logins are not retried to avoid lockouts.
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	return FailureReason(err) != ReasonAuth
+}
+
+// waitForRetry sleeps for a jittered exponential backoff, the n-th retry waits up to base * 2^n
+func waitForRetry(ctx context.Context, base time.Duration, n int) error {
+	if n > maxBackoffExponent {
+		n = maxBackoffExponent
+	}
+	max := base << uint(n)
+	wait := max/2 + time.Duration(rand.Int63n(int64(max/2)+1))
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Transport runs commands on a device
+type Transport interface {
+	// RunCommand runs a command and returns its output
+	RunCommand(ctx context.Context, cmd string) (string, error)
+
+	// Close closes the transport
+	Close()
+
+	// String returns the address of the device
+	String() string
+
+	// SetOS selects the OS specific handling of the output once the OS of the device is known
+	SetOS(os string)
+}
+
+// RawTransport is implemented by transports able to return the output of a command before it is sanitized
+type RawTransport interface {
+	Transport
+
+	// RunCommandRaw runs a command and returns its output as sent by the device
+	RunCommandRaw(ctx context.Context, cmd string) (string, error)
+
+	// Sanitize cleans the raw output of cmd the way RunCommand does
+	Sanitize(output, cmd string) string
+}
+
 // SSHConnection encapsulates the connection to the device
 type SSHConnection struct {
-	client       *ssh.Client
-	Host         string
-	stdin        io.WriteCloser
-	stdout       io.Reader
-	session      *ssh.Session
-	batchSize    int
+	client    *ssh.Client
+	Host      string
+	stdin     io.WriteCloser
+	output    *outputReader
+	session   *ssh.Session
+	batchSize int
+	maxOutput int
+	prompt    *regexp.Regexp
+	ptyMode   string
+	pty       bool
+	// echo is set if the device echoed the last command, commands are only batched then
+	echo         bool
+	sanitizer    *OutputSanitizer
 	clientConfig *ssh.ClientConfig
+	dialer       Dialer
+	done         chan struct{}
+	closeOnce    sync.Once
+	dead         int32
+	timings      []PhaseTiming
+	loginInfo    LoginInfo
+	// privilege is the privilege escalation command of the OS, its password prompt is answered with enablePassword
+	privilege      string
+	passwordPrompt *regexp.Regexp
+	enablePassword string
 }
 
-// Connect connects to the device
-func (c *SSHConnection) Connect() error {
-	var err error
-	c.client, err = ssh.Dial("tcp", c.Host, c.clientConfig)
+// Connect connects to the device. Failures are returned as *ConnectError.
+func (c *SSHConnection) Connect(ctx context.Context) error {
+	c.timings = nil
+	t := time.Now()
+
+	conn, err := c.dialer.DialContext(ctx, "tcp", c.Host)
+	if err != nil {
+		return c.connectError(dialFailureReason(err), err)
+	}
+	t = c.recordPhase(PhaseDial, t, time.Now())
+
+	// the host key is verified right after the key exchange, everything afterwards is authentication
+	var handshakeDone time.Time
+	clientConfig := *c.clientConfig
+	clientConfig.HostKeyCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		handshakeDone = time.Now()
+		return c.clientConfig.HostKeyCallback(hostname, remote, key)
+	}
+	var banner string
+	clientConfig.BannerCallback = func(message string) error {
+		banner += message
+		return nil
+	}
+
+	// the handshake itself is not context aware, so bound it by the deadline of ctx
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, c.Host, &clientConfig)
 	if err != nil {
-		return err
+		conn.Close()
+		if handshakeDone.IsZero() {
+			return c.connectError(ReasonHandshake, err)
+		}
+		c.recordPhase(PhaseHandshake, t, handshakeDone)
+		return c.connectError(ReasonAuth, err)
 	}
+	conn.SetDeadline(time.Time{})
+	c.recordPhase(PhaseHandshake, t, handshakeDone)
+	t = c.recordPhase(PhaseAuth, handshakeDone, time.Now())
+	c.client = ssh.NewClient(sshConn, chans, reqs)
+
+	go c.closeOnDone(ctx)
 
 	session, err := c.client.NewSession()
 	if err != nil {
-		c.client.Conn.Close()
-		return err
+		c.Close()
+		return c.connectError(ReasonShell, err)
+	}
+	c.session = session
+
+	c.stdin, err = session.StdinPipe()
+	if err != nil {
+		c.Close()
+		return c.connectError(ReasonShell, errors.Wrap(err, "could not open stdin"))
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		c.Close()
+		return c.connectError(ReasonShell, errors.Wrap(err, "could not open stdout"))
+	}
+	c.output = newOutputReader(stdout, c.batchSize, c.maxOutput, c.done)
+
+	err = c.requestPty(session)
+	if err != nil {
+		c.Close()
+		return c.connectError(ReasonPTY, err)
+	}
+
+	err = session.Shell()
+	if err != nil {
+		c.Close()
+		return c.connectError(ReasonShell, errors.Wrap(err, "could not start shell"))
+	}
+
+	err = showLoginTips(ctx, c, banner)
+	if err != nil {
+		c.Close()
+		return c.connectError(ReasonPrompt, err)
+	}
+	c.recordPhase(PhaseShellReady, t, time.Now())
+
+	return nil
+}
+
+// requestPty requests a terminal for session. In PTYAuto mode a refused PTY falls back to a plain shell.
+func (c *SSHConnection) requestPty(session *ssh.Session) error {
+	if c.ptyMode == PTYNone {
+		return nil
 	}
-	c.stdin, _ = session.StdinPipe()
-	c.stdout, _ = session.StdoutPipe()
-	// modes := ssh.TerminalModes{
-	// 	ssh.ECHO:  0,
-	// 	ssh.OCRNL: 0,
-	// }
-	// session.RequestPty("vt100", 0, 2000, modes)
 
 	modes := ssh.TerminalModes{
 		ssh.ECHO:          0,     // 禁用回显（0禁用，1启动）
 		ssh.TTY_OP_ISPEED: 14400, // input speed = 14.4kbaud
 		ssh.TTY_OP_OSPEED: 14400, //output speed = 14.4kbaud
 	}
-	if err = session.RequestPty("linux", 32, 160, modes); err != nil {
-		log.Fatalf("request pty error: %s", err.Error())
+	err := session.RequestPty("linux", 32, 160, modes)
+	if err == nil {
+		c.pty = true
+		return nil
 	}
 
-	session.Shell()
-	c.session = session
+	if c.ptyMode == PTYRequired {
+		return errors.Wrap(err, "PTY request refused")
+	}
+	log.Printf("%s: PTY request refused, falling back to a shell without PTY: %s", c.Host, err)
 
-	showLoginTips(c)
-	// c.RunCommand("uname -a")
-	// c.RunCommand("show version")
-	// c.RunCommand("display version")
-	// c.RunCommand("terminal length 0")
+	return nil
+}
+
+func showLoginTips(ctx context.Context, c *SSHConnection, banner string) error {
+	output, err := c.output.readUntil(ctx, c.prompt, c.clientConfig.Timeout)
+	if err != nil {
+		return errors.Wrap(err, "could not find prompt after login")
+	}
+	if inConfigMode(output) {
+		return ErrConfigMode
+	}
+	c.loginInfo = parseLoginOutput(banner, output, c.prompt)
 
 	return nil
 }
 
-type result struct {
-	output string
-	err    error
+// RunCommand runs a command against the device. A command still running when ctx is done is abandoned.
+func (c *SSHConnection) RunCommand(ctx context.Context, cmd string) (string, error) {
+	output, err := c.RunCommandRaw(ctx, cmd)
+
+	return c.Sanitize(output, cmd), err
 }
 
-func showLoginTips(c *SSHConnection) {
-	buf := bufio.NewReader(c.stdout)
-	outputChan := make(chan result)
-	go func() {
-		c.readln(outputChan, "", buf)
-	}()
-	select {
-	case res := <-outputChan:
-		log.Printf(res.output)
+// RunCommandRaw runs a command against the device and returns its output as sent by the device, including echo and prompt
+func (c *SSHConnection) RunCommandRaw(ctx context.Context, cmd string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if !c.Alive() {
+		return "", ErrSessionDead
+	}
+
+	_, err := io.WriteString(c.stdin, cmd+"\n")
+	if err != nil {
+		return "", err
 	}
+
+	var output string
+	if cmd == c.privilege {
+		output, err = c.output.readUntil(ctx, privilegePrompt(c.prompt, c.passwordPrompt), c.clientConfig.Timeout)
+		if err == nil && !c.prompt.MatchString(output) {
+			output, err = c.answerPassword(ctx, output)
+		}
+	} else {
+		output, err = c.output.readUntil(ctx, c.prompt, c.clientConfig.Timeout)
+	}
+	if err != nil && !c.Alive() {
+		return "", ErrSessionDead
+	}
+	if err == nil {
+		c.echo = echoed(output, cmd)
+	}
+	if err != nil && !errors.Is(err, ErrOutputLimitExceeded) {
+		// the output of the command could still arrive and would be mistaken for the output of the next one
+		c.Close()
+	}
+	if inConfigMode(output) {
+		log.Printf("%s: %s after %q", c.Host, ErrConfigMode, cmd)
+		c.Close()
+		return "", ErrConfigMode
+	}
+
+	return output, err
+}
+
+// Sanitize cleans the raw output of cmd with the rules of the OS of the device. There is no echo to strip unless the device echoed cmd.
+func (c *SSHConnection) Sanitize(output, cmd string) string {
+	if !c.echo {
+		return c.sanitizer.Clean(output, "")
+	}
+
+	return c.sanitizer.Clean(output, cmd)
+}
+
+// SetOS selects prompt, privilege escalation and sanitizer rules of os
+func (c *SSHConnection) SetOS(os string) {
+	d := driver.ForOS(os)
+	c.prompt = d.Prompt
+	c.privilege = d.Privilege
+	c.passwordPrompt = d.PrivilegePrompt
+	c.sanitizer.SetOS(os)
+}
+
+// Timings returns the time spent in each phase of establishing the connection
+func (c *SSHConnection) Timings() []PhaseTiming {
+	return c.timings
 }
 
-// RunCommand runs a command against the device
-func (c *SSHConnection) RunCommand(cmd string) (string, error) {
-	buf := bufio.NewReader(c.stdout)
-	io.WriteString(c.stdin, cmd+"\n")
+// recordPhase records the duration of a phase and returns its end
+func (c *SSHConnection) recordPhase(phase string, start, end time.Time) time.Time {
+	c.timings = append(c.timings, PhaseTiming{Phase: phase, Duration: end.Sub(start)})
 
-	outputChan := make(chan result)
-	go func() {
-		c.readln(outputChan, cmd, buf)
-	}()
+	return end
+}
+
+func (c *SSHConnection) connectError(reason string, err error) error {
+	return &ConnectError{Reason: reason, Err: err, Timings: c.timings}
+}
+
+func (c *SSHConnection) String() string {
+	return c.Host
+}
+
+// closeOnDone tears down the connection when ctx is done, which terminates any command still in flight
+func (c *SSHConnection) closeOnDone(ctx context.Context) {
 	select {
-	case res := <-outputChan:
-		return res.output, res.err
-	case <-time.After(c.clientConfig.Timeout):
-		return "", errors.New("Timeout reached")
+	case <-ctx.Done():
+		c.Close()
+	case <-c.done:
 	}
 }
 
 // Close closes connection
 func (c *SSHConnection) Close() {
-	if c.client.Conn == nil {
-		return
-	}
-	c.client.Conn.Close()
-	if c.session != nil {
-		c.session.Close()
-	}
+	c.closeOnce.Do(func() {
+		close(c.done)
+		if c.session != nil {
+			c.session.Close()
+		}
+		if c.client != nil {
+			c.client.Conn.Close()
+		}
+	})
 }
 
 func loadPrivateKey(r io.Reader) (ssh.AuthMethod, error) {
@@ -170,24 +465,3 @@ func loadPrivateKey(r io.Reader) (ssh.AuthMethod, error) {
 
 	return ssh.PublicKeys(key), nil
 }
-
-func (c *SSHConnection) readln(ch chan result, cmd string, r io.Reader) {
-	re := regexp.MustCompile(`.+#\s?$`)
-	buf := make([]byte, c.batchSize)
-	loadStr := ""
-	for {
-		n, err := r.Read(buf)
-		if err != nil {
-			ch <- result{output: "", err: err}
-		}
-		// log.Printf("--> n=%s line: "+string(buf[:n]), n)
-		loadStr += string(buf[:n])
-		if re.MatchString(loadStr) {
-			// if strings.Contains(loadStr, cmd) && re.MatchString(loadStr) {
-			break
-		}
-	}
-	// log.Println("--> total: " + loadStr)
-	loadStr = strings.Replace(loadStr, "\r", "", -1)
-	ch <- result{output: loadStr, err: nil}
-}
@@ -0,0 +1,346 @@
+package connector
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/shenjler/ssh_ping_exporter/config"
+	"github.com/shenjler/ssh_ping_exporter/fakedevice"
+)
+
+// startDevice starts a fake device and returns a device of the exporter pointing to it
+func startDevice(t *testing.T, dev fakedevice.Device) *Device {
+	t.Helper()
+
+	s, err := fakedevice.Start("127.0.0.1:0", dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	host, port, err := net.SplitHostPort(s.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Device{
+		Host:         host,
+		Port:         port,
+		Auth:         AuthByPassword("exporter", "secret"),
+		DeviceConfig: &config.DeviceConfig{Host: s.Addr()},
+	}
+}
+
+// connect connects to device with cfg, the connection is closed at the end of the test
+func connect(t *testing.T, device *Device, cfg *config.Config) *SSHConnection {
+	t.Helper()
+
+	c, err := NewSSSHConnection(context.Background(), device, cfg)
+	if err != nil {
+		t.Fatalf("could not connect: %s", err)
+	}
+	t.Cleanup(c.Close)
+
+	return c
+}
+
+// expectedOutput is a response of a fake device as the sanitized output of the command
+func expectedOutput(response string) string {
+	return strings.TrimSuffix(response, "\n")
+}
+
+func TestConnect(t *testing.T) {
+	tests := []struct {
+		name       string
+		device     fakedevice.Device
+		os         string
+		cmd        string
+		banner     string
+		motd       string
+		lastLogin  string
+		lastSource string
+	}{
+		{
+			name:       "linux",
+			device:     fakedevice.Linux("host1"),
+			os:         "LINUX",
+			cmd:        "uname -a",
+			motd:       "Welcome to Ubuntu 20.04.6 LTS (GNU/Linux 5.4.0-150-generic x86_64)",
+			lastLogin:  "Mon Oct 12 09:14:02 2026",
+			lastSource: "10.0.0.10",
+		},
+		{
+			name:   "cisco",
+			device: fakedevice.Cisco("rtr1"),
+			os:     "IOSXE",
+			cmd:    "show version",
+			banner: "*** Authorized access only ***",
+			motd:   "rtr1 - lab router",
+		},
+		{
+			name:       "huawei",
+			device:     fakedevice.Huawei("sw1"),
+			os:         "VRP",
+			cmd:        "display version",
+			lastLogin:  "2026-10-17 09:30:12+08:00",
+			lastSource: "10.0.0.10",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := connect(t, startDevice(t, test.device), config.New())
+
+			info := c.LoginInfo()
+			if info.Banner != test.banner {
+				t.Errorf("banner: got %q, expected %q", info.Banner, test.banner)
+			}
+			if info.MOTD != test.motd {
+				t.Errorf("MOTD: got %q, expected %q", info.MOTD, test.motd)
+			}
+			if info.LastLoginTime != test.lastLogin || info.LastLoginSource != test.lastSource {
+				t.Errorf("last login: got %q from %q, expected %q from %q", info.LastLoginTime, info.LastLoginSource, test.lastLogin, test.lastSource)
+			}
+
+			phases := make(map[string]bool)
+			for _, p := range c.Timings() {
+				phases[p.Phase] = true
+			}
+			for _, p := range []string{PhaseDial, PhaseHandshake, PhaseAuth, PhaseShellReady} {
+				if !phases[p] {
+					t.Errorf("no timing of phase %s", p)
+				}
+			}
+
+			c.SetOS(test.os)
+			out, err := c.RunCommand(context.Background(), test.cmd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected := expectedOutput(test.device.Responses[test.cmd]); out != expected {
+				t.Errorf("output of %q:\n%q\nexpected:\n%q", test.cmd, out, expected)
+			}
+		})
+	}
+}
+
+func TestUnknownCommand(t *testing.T) {
+	dev := fakedevice.Cisco("rtr1")
+	c := connect(t, startDevice(t, dev), config.New())
+	c.SetOS("IOSXE")
+
+	out, err := c.RunCommand(context.Background(), "show foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != dev.UnknownCommand {
+		t.Errorf("got %q, expected %q", out, dev.UnknownCommand)
+	}
+}
+
+func TestOutputEndingWithCommand(t *testing.T) {
+	for _, echo := range []bool{true, false} {
+		dev := fakedevice.Cisco("rtr1")
+		dev.Echo = echo
+		dev.Responses["show history"] = ciscoHistory
+
+		c := connect(t, startDevice(t, dev), config.New())
+		c.SetOS("IOSXE")
+
+		out, err := c.RunCommand(context.Background(), "show history")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != ciscoHistory {
+			t.Errorf("echo %v: got %q, expected %q", echo, out, ciscoHistory)
+		}
+	}
+}
+
+func TestPager(t *testing.T) {
+	dev := fakedevice.Cisco("rtr1")
+	c := connect(t, startDevice(t, dev), config.New())
+	c.SetOS("IOSXE")
+
+	out, err := c.RunCommand(context.Background(), dev.PagerDisable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "" {
+		t.Errorf("unexpected output of %q: %q", dev.PagerDisable, out)
+	}
+
+	// the output is longer than a page of the pager
+	out, err = c.RunCommand(context.Background(), "show interface")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := expectedOutput(dev.Responses["show interface"]); out != expected {
+		t.Errorf("output of show interface:\n%q\nexpected:\n%q", out, expected)
+	}
+}
+
+func TestPagerPromptIsNotAPrompt(t *testing.T) {
+	dev := fakedevice.Cisco("rtr1")
+	cfg := config.New()
+	cfg.Timeout = 1
+	c := connect(t, startDevice(t, dev), cfg)
+	c.SetOS("IOSXE")
+
+	// without disabling the pager the device waits for a key at the first page
+	_, err := c.RunCommand(context.Background(), "show interface")
+	if err == nil {
+		t.Fatalf("expected a timeout while the pager waits")
+	}
+	// the rest of the output would be mistaken for the output of the next command
+	select {
+	case <-c.done:
+	default:
+		t.Errorf("session with pending output was not closed")
+	}
+}
+
+func TestPTYRefused(t *testing.T) {
+	dev := fakedevice.Linux("host1")
+	dev.RefusePTY = true
+
+	t.Run("auto", func(t *testing.T) {
+		c := connect(t, startDevice(t, dev), config.New())
+		if c.pty {
+			t.Errorf("expected a session without PTY")
+		}
+
+		c.SetOS("LINUX")
+		out, err := c.RunCommand(context.Background(), "uname -a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := expectedOutput(dev.Responses["uname -a"]); out != expected {
+			t.Errorf("got %q, expected %q", out, expected)
+		}
+	})
+
+	t.Run("required", func(t *testing.T) {
+		cfg := config.New()
+		cfg.PTY = PTYRequired
+
+		_, err := NewSSSHConnection(context.Background(), startDevice(t, dev), cfg)
+		if err == nil {
+			t.Fatal("expected the connection to fail")
+		}
+		if reason := FailureReason(err); reason != ReasonPTY {
+			t.Errorf("got reason %q, expected %q", reason, ReasonPTY)
+		}
+	})
+}
+
+func TestMaxOutput(t *testing.T) {
+	dev := fakedevice.Cisco("rtr1")
+	dev.PagerLines = 0
+	cfg := config.New()
+	cfg.MaxOutput = 1024
+	c := connect(t, startDevice(t, dev), cfg)
+	c.SetOS("IOSXE")
+
+	out, err := c.RunCommand(context.Background(), "show interface")
+	if !errors.Is(err, ErrOutputLimitExceeded) {
+		t.Fatalf("expected %s, got %v", ErrOutputLimitExceeded, err)
+	}
+	if len(out) > cfg.MaxOutput {
+		t.Errorf("output of %d bytes exceeds the limit of %d bytes", len(out), cfg.MaxOutput)
+	}
+
+	// the session stays usable, the rest of the output is not mistaken for the output of the next command
+	out, err = c.RunCommand(context.Background(), "show running-config | include hostname")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "hostname rtr1" {
+		t.Errorf("got %q after the truncated output", out)
+	}
+}
+
+func TestEnable(t *testing.T) {
+	tests := []struct {
+		name           string
+		devicePassword string
+		enablePassword string
+		err            error
+	}{
+		{name: "no password", devicePassword: "", enablePassword: ""},
+		{name: "password", devicePassword: "enable-secret", enablePassword: "enable-secret"},
+		{name: "password required", devicePassword: "enable-secret", enablePassword: "", err: ErrPasswordRequired},
+		{name: "password rejected", devicePassword: "enable-secret", enablePassword: "wrong", err: ErrPasswordRejected},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dev := fakedevice.Cisco("rtr1")
+			dev.EnablePassword = test.devicePassword
+			device := startDevice(t, dev)
+			device.EnablePassword = test.enablePassword
+			c := connect(t, device, config.New())
+			c.SetOS("IOSXE")
+
+			_, err := c.RunCommand(context.Background(), "enable")
+			if test.err != nil {
+				if !errors.Is(err, test.err) {
+					t.Fatalf("expected %s, got %v", test.err, err)
+				}
+				select {
+				case <-c.done:
+				default:
+					t.Errorf("session waiting for a password was not closed")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			out, err := c.RunCommand(context.Background(), "show running-config | include hostname")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out != "hostname rtr1" {
+				t.Errorf("got %q after enable", out)
+			}
+		})
+	}
+}
+
+func TestConnectFailures(t *testing.T) {
+	t.Run("auth", func(t *testing.T) {
+		dev := fakedevice.Linux("host1")
+		dev.Username = "admin"
+		dev.Password = "other"
+
+		_, err := NewSSSHConnection(context.Background(), startDevice(t, dev), config.New())
+		if reason := FailureReason(err); reason != ReasonAuth {
+			t.Errorf("got reason %q (%v), expected %q", reason, err, ReasonAuth)
+		}
+	})
+
+	t.Run("refused", func(t *testing.T) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		host, port, _ := net.SplitHostPort(l.Addr().String())
+		l.Close()
+
+		d := &Device{
+			Host:         host,
+			Port:         port,
+			Auth:         AuthByPassword("exporter", "secret"),
+			DeviceConfig: &config.DeviceConfig{Host: l.Addr().String()},
+		}
+		_, err = NewSSSHConnection(context.Background(), d, config.New())
+		if reason := FailureReason(err); reason != ReasonTCPRefused {
+			t.Errorf("got reason %q (%v), expected %q", reason, err, ReasonTCPRefused)
+		}
+	})
+}
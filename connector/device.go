@@ -3,16 +3,30 @@ package connector
 import (
 	"io"
 
+	"github.com/pkg/errors"
 	"github.com/shenjler/ssh_ping_exporter/config"
 	"golang.org/x/crypto/ssh"
 )
 
+// PTY modes of the shell session
+const (
+	// PTYAuto requests a PTY and falls back to a shell without PTY if the device refuses it
+	PTYAuto = "auto"
+	// PTYRequired fails the connection if the device refuses a PTY
+	PTYRequired = "required"
+	// PTYNone never requests a PTY
+	PTYNone = "none"
+)
+
 type Device struct {
 	Host         string
 	Port         string
+	Username     string
 	Auth         AuthMethod
 	ClientConfig ssh.ClientConfig
 	DeviceConfig *config.DeviceConfig
+	// EnablePassword answers the password prompt of the privilege escalation command
+	EnablePassword string
 }
 
 // AuthMethod is the method to use to authenticate agaist the device
@@ -41,3 +55,53 @@ func AuthByKey(username string, key io.Reader) (AuthMethod, error) {
 func (d *Device) String() string {
 	return d.Host
 }
+
+// CheckDevice verifies the connection settings of a device can be used
+func CheckDevice(device *Device, cfg *config.Config) error {
+	_, err := algorithmsForDevice(device.DeviceConfig, cfg)
+	if err != nil {
+		return errors.Wrap(err, "invalid SSH algorithms")
+	}
+
+	switch ptyModeForDevice(device.DeviceConfig, cfg) {
+	case PTYAuto, PTYRequired, PTYNone:
+	default:
+		return errors.Errorf("invalid PTY mode %q", ptyModeForDevice(device.DeviceConfig, cfg))
+	}
+
+	_, err = sanitizersForConfig(cfg)
+	if err != nil {
+		return errors.Wrap(err, "invalid sanitizers")
+	}
+
+	_, err = dialerForProxy(proxyForDevice(device.DeviceConfig, cfg), 0)
+	if err != nil {
+		return errors.Wrap(err, "invalid proxy")
+	}
+
+	return nil
+}
+
+func proxyForDevice(deviceConfig *config.DeviceConfig, cfg *config.Config) string {
+	if deviceConfig.Proxy != nil {
+		return *deviceConfig.Proxy
+	}
+
+	return cfg.Proxy
+}
+
+func ptyModeForDevice(deviceConfig *config.DeviceConfig, cfg *config.Config) string {
+	if deviceConfig.PTY != nil {
+		return *deviceConfig.PTY
+	}
+	if cfg.PTY != "" {
+		return cfg.PTY
+	}
+
+	return PTYAuto
+}
+
+// Address returns host and port of the device
+func (d *Device) Address() string {
+	return d.Host + ":" + d.Port
+}
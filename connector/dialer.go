@@ -0,0 +1,116 @@
+package connector
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/proxy"
+)
+
+// Dialer opens the network connection the SSH session runs over
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// dialerForProxy returns a dialer connecting through proxyURL, an empty URL dials directly.
+// Supported are socks5://[user:password@]host:port and http://[user:password@]host:port (HTTP CONNECT).
+func dialerForProxy(proxyURL string, timeout time.Duration) (Dialer, error) {
+	direct := &net.Dialer{Timeout: timeout}
+	if proxyURL == "" {
+		return direct, nil
+	}
+
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid proxy URL")
+	}
+
+	switch u.Scheme {
+	case "socks5", "socks5h":
+		var auth *proxy.Auth
+		if u.User != nil {
+			password, _ := u.User.Password()
+			auth = &proxy.Auth{User: u.User.Username(), Password: password}
+		}
+
+		d, err := proxy.SOCKS5("tcp", u.Host, auth, direct)
+		if err != nil {
+			return nil, err
+		}
+
+		cd, ok := d.(Dialer)
+		if !ok {
+			return nil, errors.New("SOCKS5 dialer does not support contexts")
+		}
+
+		return cd, nil
+	case "http":
+		return &httpConnectDialer{proxy: u, forward: direct}, nil
+	default:
+		return nil, errors.Errorf("unsupported proxy scheme %q", u.Scheme)
+	}
+}
+
+// httpConnectDialer tunnels connections through an HTTP proxy using the CONNECT method
+type httpConnectDialer struct {
+	proxy   *url.URL
+	forward *net.Dialer
+}
+
+// DialContext connects to address through the proxy
+func (d *httpConnectDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	conn, err := d.forward.DialContext(ctx, network, d.proxy.Host)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not connect to proxy")
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	req := fmt.Sprintf("CONNECT %s HTTP/1.1\r\nHost: %s\r\n", address, address)
+	if d.proxy.User != nil {
+		password, _ := d.proxy.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(d.proxy.User.Username() + ":" + password))
+		req += "Proxy-Authorization: Basic " + credentials + "\r\n"
+	}
+	_, err = conn.Write([]byte(req + "\r\n"))
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "could not send CONNECT to proxy")
+	}
+
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, &http.Request{Method: "CONNECT"})
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "could not read CONNECT response of proxy")
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, errors.Errorf("proxy refused CONNECT to %s: %s", address, resp.Status)
+	}
+
+	conn.SetDeadline(time.Time{})
+
+	// the SSH server may have sent its version already, it must not get lost in the reader
+	return &bufferedConn{Conn: conn, r: r}, nil
+}
+
+// bufferedConn is a connection whose first bytes were already read into r
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
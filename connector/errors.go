@@ -0,0 +1,94 @@
+package connector
+
+import (
+	"context"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Reasons a connection to a device failed
+const (
+	ReasonDNS         = "dns"
+	ReasonTCPRefused  = "tcp_refused"
+	ReasonTCPTimeout  = "tcp_timeout"
+	ReasonTCP         = "tcp"
+	ReasonHandshake   = "ssh_handshake"
+	ReasonAuth        = "auth"
+	ReasonPTY         = "pty_refused"
+	ReasonShell       = "pty_shell"
+	ReasonPrompt      = "prompt_not_found"
+	ReasonCircuitOpen = "circuit_open"
+	ReasonUnknown     = "unknown"
+)
+
+// Phases of establishing a connection
+const (
+	PhaseDial       = "dial"
+	PhaseHandshake  = "handshake"
+	PhaseAuth       = "auth"
+	PhaseShellReady = "shell_ready"
+)
+
+// PhaseTiming is the time spent in one phase of establishing a connection
+type PhaseTiming struct {
+	Phase    string
+	Duration time.Duration
+}
+
+// ConnectError is returned when a connection could not be established
+type ConnectError struct {
+	Reason string
+	Err    error
+	// Timings of the phases completed before the failure
+	Timings []PhaseTiming
+}
+
+func (e *ConnectError) Error() string {
+	return e.Reason + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *ConnectError) Unwrap() error {
+	return e.Err
+}
+
+// Cause returns the underlying error
+func (e *ConnectError) Cause() error {
+	return e.Err
+}
+
+// FailureReason classifies an error returned by NewSSSHConnection
+func FailureReason(err error) string {
+	if errors.Is(err, ErrCircuitOpen) {
+		return ReasonCircuitOpen
+	}
+
+	var ce *ConnectError
+	if errors.As(err, &ce) {
+		return ce.Reason
+	}
+
+	return ReasonUnknown
+}
+
+// dialFailureReason classifies an error of opening the TCP connection
+func dialFailureReason(err error) string {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return ReasonDNS
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return ReasonTCPRefused
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return ReasonTCPTimeout
+	}
+
+	return ReasonTCP
+}
@@ -0,0 +1,88 @@
+package connector
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrSessionDead is returned for commands on a connection which stopped answering keepalives
+var ErrSessionDead = errors.New("session is dead")
+
+var (
+	keepaliveFailuresMu sync.Mutex
+	keepaliveFailures   = make(map[string]uint64)
+)
+
+// KeepaliveFailures returns the number of unanswered keepalives over all connections to address
+func KeepaliveFailures(address string) float64 {
+	keepaliveFailuresMu.Lock()
+	defer keepaliveFailuresMu.Unlock()
+
+	return float64(keepaliveFailures[address])
+}
+
+func recordKeepaliveFailure(address string) {
+	keepaliveFailuresMu.Lock()
+	defer keepaliveFailuresMu.Unlock()
+
+	keepaliveFailures[address]++
+}
+
+// Alive reports whether the device still answers keepalives
+func (c *SSHConnection) Alive() bool {
+	return atomic.LoadInt32(&c.dead) == 0
+}
+
+// keepalive sends a keepalive every interval and closes the connection after maxMissed unanswered keepalives in a row
+func (c *SSHConnection) keepalive(interval time.Duration, maxMissed int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	missed := 0
+	for {
+		select {
+		case <-ticker.C:
+		case <-c.done:
+			return
+		}
+
+		if c.sendKeepalive(interval) {
+			missed = 0
+			continue
+		}
+
+		missed++
+		recordKeepaliveFailure(c.Host)
+		if missed >= maxMissed {
+			log.Printf("%s: %d keepalives unanswered, closing connection", c.Host, missed)
+			atomic.StoreInt32(&c.dead, 1)
+			c.Close()
+			return
+		}
+	}
+}
+
+// sendKeepalive reports whether the device replied in time. Any reply counts, even a rejection of the request.
+func (c *SSHConnection) sendKeepalive(timeout time.Duration) bool {
+	replied := make(chan error, 1)
+	go func() {
+		_, _, err := c.client.SendRequest("keepalive@openssh.com", true, nil)
+		replied <- err
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-replied:
+		return err == nil
+	case <-timer.C:
+		return false
+	case <-c.done:
+		return true
+	}
+}
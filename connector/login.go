@@ -0,0 +1,134 @@
+package connector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var lastLoginRegexps = []*regexp.Regexp{
+	// Linux: Last login: Mon Oct 12 09:14:02 2026 from 10.0.0.10
+	regexp.MustCompile(`^\s*Last login:\s+(.+?)(?:\s+from\s+(\S+))?(?:\s+on\s+\S+)?\s*$`),
+	// VRP: The last login time is 2026-10-17 09:30:12+08:00 from 10.0.0.10 through SSH.
+	regexp.MustCompile(`^\s*(?:Info:\s+)?The last login time is\s+(.+?)(?:\s+from\s+(\S+?))?(?:\s+through\s+\S+?)?\.?\s*$`),
+}
+
+// lastLoginLayouts are the formats of the time of the previous login, times without zone are taken as UTC
+var lastLoginLayouts = []string{
+	// Linux
+	"Mon Jan _2 15:04:05 2006",
+	"Mon Jan _2 15:04:05 2006 MST",
+	// VRP
+	"2006-01-02 15:04:05-07:00",
+	"2006-01-02 15:04:05",
+}
+
+// summaryLength is the maximum length in characters of banner and MOTD summaries
+const summaryLength = 64
+
+// volatileLoginRegexp matches lines changing with every login, they are not part of the MOTD
+var volatileLoginRegexp = regexp.MustCompile(`^\s*(?:Info:\s+)?(?:The current login time is|The max number of VTY users)`)
+
+// LoginInfo is what a device printed when logging in
+type LoginInfo struct {
+	// Banner is the SSH banner sent before authentication
+	Banner string `json:"banner,omitempty" yaml:"banner,omitempty"`
+	// MOTD is the output after login up to the first prompt without the last login and other volatile lines
+	MOTD string `json:"motd,omitempty" yaml:"motd,omitempty"`
+	// LastLoginTime and LastLoginSource describe the previous login as printed by the device (Linux, VRP)
+	LastLoginTime   string `json:"last_login_time,omitempty" yaml:"last_login_time,omitempty"`
+	LastLoginSource string `json:"last_login_source,omitempty" yaml:"last_login_source,omitempty"`
+}
+
+// LastLogin returns the time of the previous login, false if the device printed none or in an unknown format
+func (l LoginInfo) LastLogin() (time.Time, bool) {
+	for _, layout := range lastLoginLayouts {
+		if t, err := time.Parse(layout, l.LastLoginTime); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// Hash returns a hash of banner and MOTD to detect changes
+func (l LoginInfo) Hash() string {
+	h := sha256.Sum256([]byte(l.Banner + "\x00" + l.MOTD))
+
+	return hex.EncodeToString(h[:])
+}
+
+// BannerSummary returns the first line of the banner shortened to a length usable as label value
+func (l LoginInfo) BannerSummary() string {
+	return summary(l.Banner)
+}
+
+// MOTDSummary returns the first line of the MOTD shortened to a length usable as label value
+func (l LoginInfo) MOTDSummary() string {
+	return summary(l.MOTD)
+}
+
+// summary returns the first non-empty line of text with at most summaryLength characters
+func summary(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if r := []rune(line); len(r) > summaryLength {
+			return string(r[:summaryLength-3]) + "..."
+		}
+		return line
+	}
+
+	return ""
+}
+
+// LoginInfoTransport is implemented by transports knowing what the device printed at login
+type LoginInfoTransport interface {
+	LoginInfo() LoginInfo
+}
+
+// LoginInfo returns banner, MOTD and last login of the device
+func (c *SSHConnection) LoginInfo() LoginInfo {
+	return c.loginInfo
+}
+
+// parseLoginOutput extracts MOTD and last login from the output between login and the first prompt
+func parseLoginOutput(banner, output string, prompt *regexp.Regexp) LoginInfo {
+	info := LoginInfo{Banner: strings.TrimSpace(ansiEscapeRegexp.ReplaceAllString(banner, ""))}
+
+	output = ansiEscapeRegexp.ReplaceAllString(applyBackspaces(output), "")
+	lines := strings.Split(output, "\n")
+	if len(lines) > 0 && prompt.MatchString(lines[len(lines)-1]) {
+		lines = lines[:len(lines)-1]
+	}
+
+	motd := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if matches := lastLoginMatch(line); matches != nil {
+			info.LastLoginTime = matches[1]
+			info.LastLoginSource = matches[2]
+			continue
+		}
+		if volatileLoginRegexp.MatchString(line) {
+			continue
+		}
+		motd = append(motd, strings.TrimRight(line, " \t"))
+	}
+	info.MOTD = strings.Trim(strings.Join(motd, "\n"), "\n")
+
+	return info
+}
+
+func lastLoginMatch(line string) []string {
+	for _, r := range lastLoginRegexps {
+		if matches := r.FindStringSubmatch(line); matches != nil {
+			return matches
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package connector
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoginInfoSummary(t *testing.T) {
+	long := strings.Repeat("x", 100)
+
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{name: "empty", text: "", expected: ""},
+		{name: "single line", text: "*** Authorized access only ***", expected: "*** Authorized access only ***"},
+		{name: "first line", text: "\n  \n  rtr1 - lab router  \nsecond line\n", expected: "rtr1 - lab router"},
+		{name: "long line", text: long, expected: long[:61] + "..."},
+		{name: "multibyte", text: strings.Repeat("é", 80), expected: strings.Repeat("é", 61) + "..."},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			info := LoginInfo{Banner: test.text, MOTD: test.text}
+			if got := info.BannerSummary(); got != test.expected {
+				t.Errorf("banner: got %q, expected %q", got, test.expected)
+			}
+			if got := info.MOTDSummary(); got != test.expected {
+				t.Errorf("MOTD: got %q, expected %q", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestLoginInfoLastLogin(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected time.Time
+		ok       bool
+	}{
+		{name: "none", text: ""},
+		{name: "linux", text: "Mon Oct 12 09:14:02 2026", expected: time.Date(2026, 10, 12, 9, 14, 2, 0, time.UTC), ok: true},
+		{name: "linux single digit day", text: "Fri Oct  2 09:14:02 2026", expected: time.Date(2026, 10, 2, 9, 14, 2, 0, time.UTC), ok: true},
+		{name: "vrp", text: "2026-10-17 09:30:12+08:00", expected: time.Date(2026, 10, 17, 1, 30, 12, 0, time.UTC), ok: true},
+		{name: "unknown format", text: "yesterday"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := LoginInfo{LastLoginTime: test.text}.LastLogin()
+			if ok != test.ok || !got.Equal(test.expected) {
+				t.Errorf("got %s, %v, expected %s, %v", got, ok, test.expected, test.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package connector
+
+import (
+	"context"
+	"io"
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrPasswordRequired is returned when the privilege escalation command asks for a password and none is configured. The session is closed.
+	ErrPasswordRequired = errors.New("privilege escalation asks for a password, no enable password configured")
+	// ErrPasswordRejected is returned when the device asks for the enable password again. The session is closed.
+	ErrPasswordRejected = errors.New("enable password rejected")
+)
+
+// privilegePrompt matches the prompt of the device as well as the password prompt of the privilege escalation command
+func privilegePrompt(prompt, passwordPrompt *regexp.Regexp) *regexp.Regexp {
+	if passwordPrompt == nil {
+		return prompt
+	}
+
+	return regexp.MustCompile(`(?:` + prompt.String() + `)|(?:` + passwordPrompt.String() + `)`)
+}
+
+// answerPassword sends the enable password after output ended with the password prompt and returns the output up to the prompt
+func (c *SSHConnection) answerPassword(ctx context.Context, output string) (string, error) {
+	if c.enablePassword == "" {
+		return output, ErrPasswordRequired
+	}
+
+	_, err := io.WriteString(c.stdin, c.enablePassword+"\n")
+	if err != nil {
+		return output, err
+	}
+
+	more, err := c.output.readUntil(ctx, privilegePrompt(c.prompt, c.passwordPrompt), c.clientConfig.Timeout)
+	output += more
+	if err == nil && !c.prompt.MatchString(more) {
+		return output, ErrPasswordRejected
+	}
+
+	return output, err
+}
@@ -0,0 +1,112 @@
+package connector
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// promptWindow is the number of trailing bytes the prompt is searched in
+const promptWindow = 512
+
+// ErrOutputLimitExceeded is returned when a command produces more output than allowed
+var ErrOutputLimitExceeded = errors.New("output limit exceeded")
+
+// outputReader reads from the stdout of a session for the whole lifetime of the session.
+// Chunks arriving after a command found its prompt stay buffered for the next command. The prompt has to end a chunk,
+// bytes following the prompt in the same chunk (e.g. the echo of the next command of a batch) are read as part of the output.
+type outputReader struct {
+	chunks    chan []byte
+	err       error
+	done      <-chan struct{}
+	maxOutput int
+}
+
+func newOutputReader(r io.Reader, batchSize, maxOutput int, done <-chan struct{}) *outputReader {
+	o := &outputReader{
+		chunks:    make(chan []byte, 16),
+		done:      done,
+		maxOutput: maxOutput,
+	}
+	go o.run(r, batchSize)
+
+	return o
+}
+
+// run pumps chunks from r until r fails or the session is closed
+func (o *outputReader) run(r io.Reader, batchSize int) {
+	defer close(o.chunks)
+
+	buf := make([]byte, batchSize)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			chunk := make([]byte, n)
+			copy(chunk, buf[:n])
+
+			select {
+			case o.chunks <- chunk:
+			case <-o.done:
+				o.err = io.ErrClosedPipe
+				return
+			}
+		}
+		if err != nil {
+			o.err = err
+			return
+		}
+	}
+}
+
+// readUntil collects output until the prompt is found at its end. Output beyond maxOutput is discarded while looking for the prompt,
+// if it is not found within another maxOutput bytes the output is given up.
+func (o *outputReader) readUntil(ctx context.Context, prompt *regexp.Regexp, timeout time.Duration) (string, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var output bytes.Buffer
+	tail := make([]byte, 0, 2*promptWindow)
+	truncated := false
+	discarded := 0
+	for {
+		select {
+		case chunk, ok := <-o.chunks:
+			if !ok {
+				return "", o.err
+			}
+
+			if output.Len()+len(chunk) > o.maxOutput {
+				truncated = true
+			}
+			if !truncated {
+				output.Write(chunk)
+			} else {
+				discarded += len(chunk)
+			}
+
+			tail = append(tail, chunk...)
+			if len(tail) > promptWindow {
+				tail = append(tail[:0], tail[len(tail)-promptWindow:]...)
+			}
+			if prompt.Match(tail) {
+				out := string(bytes.Replace(output.Bytes(), []byte("\r"), nil, -1))
+				if truncated {
+					return out, errors.Wrapf(ErrOutputLimitExceeded, "more than %d bytes", o.maxOutput)
+				}
+
+				return out, nil
+			}
+			if discarded > o.maxOutput {
+				return "", errors.Errorf("no prompt within %d bytes after the output limit of %d bytes", discarded, o.maxOutput)
+			}
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-timer.C:
+			return "", errors.New("Timeout reached")
+		}
+	}
+}
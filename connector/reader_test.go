@@ -0,0 +1,134 @@
+package connector
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var testPrompt = regexp.MustCompile(`(?m)^router#\s*\z`)
+
+// newTestReader returns a reader of the chunks written to the returned pipe
+func newTestReader(t *testing.T, maxOutput int) (*outputReader, *io.PipeWriter) {
+	t.Helper()
+
+	r, w := io.Pipe()
+	done := make(chan struct{})
+	t.Cleanup(func() {
+		close(done)
+		w.Close()
+	})
+
+	return newOutputReader(r, 16, maxOutput, done), w
+}
+
+func TestReadUntil(t *testing.T) {
+	tests := []struct {
+		name     string
+		chunks   []string
+		expected string
+	}{
+		{
+			name:     "one chunk",
+			chunks:   []string{"show clock\r\n10:00:00\r\nrouter#"},
+			expected: "show clock\n10:00:00\nrouter#",
+		},
+		{
+			name:     "prompt split over chunks",
+			chunks:   []string{"show clock\r\n10:00:00\r\nrou", "ter#"},
+			expected: "show clock\n10:00:00\nrouter#",
+		},
+		{
+			name:     "prompt in the middle of a line",
+			chunks:   []string{"show clock\r\nrouter# is the prompt\r\n", "router#"},
+			expected: "show clock\nrouter# is the prompt\nrouter#",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			o, w := newTestReader(t, 1<<20)
+			go func() {
+				for _, c := range test.chunks {
+					w.Write([]byte(c))
+				}
+			}()
+
+			out, err := o.readUntil(context.Background(), testPrompt, time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out != test.expected {
+				t.Errorf("got %q, expected %q", out, test.expected)
+			}
+		})
+	}
+}
+
+func TestReadUntilTimeout(t *testing.T) {
+	o, w := newTestReader(t, 1<<20)
+	go w.Write([]byte("show clock\r\n10:00:00\r\n"))
+
+	_, err := o.readUntil(context.Background(), testPrompt, 50*time.Millisecond)
+	if err == nil {
+		t.Fatal("expected a timeout")
+	}
+}
+
+func TestReadUntilCanceled(t *testing.T) {
+	o, _ := newTestReader(t, 1<<20)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := o.readUntil(ctx, testPrompt, time.Second)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected %s, got %v", context.Canceled, err)
+	}
+}
+
+func TestReadUntilMaxOutput(t *testing.T) {
+	o, w := newTestReader(t, 32)
+	go func() {
+		for i := 0; i < 3; i++ {
+			w.Write([]byte("0123456789\r\n"))
+		}
+		w.Write([]byte("router#"))
+	}()
+
+	out, err := o.readUntil(context.Background(), testPrompt, time.Second)
+	if !errors.Is(err, ErrOutputLimitExceeded) {
+		t.Fatalf("expected %s, got %v", ErrOutputLimitExceeded, err)
+	}
+	if len(out) > 32 {
+		t.Errorf("output of %d bytes exceeds the limit", len(out))
+	}
+}
+
+func TestReadUntilGivesUpAfterOutputLimit(t *testing.T) {
+	o, w := newTestReader(t, 32)
+	go func() {
+		for i := 0; i < 10; i++ {
+			w.Write([]byte("0123456789\r\n"))
+		}
+	}()
+
+	_, err := o.readUntil(context.Background(), testPrompt, time.Second)
+	if err == nil || errors.Is(err, ErrOutputLimitExceeded) {
+		t.Fatalf("expected the output to be given up, got %v", err)
+	}
+}
+
+func TestReadUntilClosed(t *testing.T) {
+	o, w := newTestReader(t, 1<<20)
+	w.Write([]byte("show clock\r\n"))
+	w.Close()
+
+	_, err := o.readUntil(context.Background(), testPrompt, time.Second)
+	if err == nil {
+		t.Fatal("expected an error after the session ended")
+	}
+}
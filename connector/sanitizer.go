@@ -0,0 +1,211 @@
+package connector
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shenjler/ssh_ping_exporter/config"
+	"github.com/shenjler/ssh_ping_exporter/driver"
+)
+
+// DefaultOS selects the sanitizer rules used for devices whose OS is not known (yet)
+const DefaultOS = "default"
+
+var ansiEscapeRegexp = regexp.MustCompile(`\x1b(?:\[[0-?]*[ -/]*[@-~]|\][^\x07\x1b]*(?:\x07|\x1b\\)|[()][0-9A-Za-z]|[@-Z\\-_])|\x07`)
+
+// builtinDropLines are dropped from the output of every OS, they are leftovers of pagers
+var builtinDropLines = []string{
+	`^\s*-+\s*[Mm]ore\s*-+\s*$`,
+}
+
+// sanitizer removes terminal artifacts from the output of a command so that only the output of the command itself remains
+type sanitizer struct {
+	stripANSI      bool
+	stripBackspace bool
+	stripEcho      bool
+	stripPrompt    bool
+	dropLines      []*regexp.Regexp
+}
+
+// OutputSanitizer cleans the outputs of the commands sent to one device with the rules of its OS
+type OutputSanitizer struct {
+	rules  map[string]*sanitizer
+	rule   *sanitizer
+	prompt *regexp.Regexp
+}
+
+// NewOutputSanitizer compiles the sanitizer rules of cfg. The default rules apply until SetOS is called.
+func NewOutputSanitizer(cfg *config.Config) (*OutputSanitizer, error) {
+	rules, err := sanitizersForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &OutputSanitizer{rules: rules}
+	s.SetOS(DefaultOS)
+
+	return s, nil
+}
+
+// SetOS selects the rules and the prompt of os
+func (s *OutputSanitizer) SetOS(os string) {
+	s.rule = sanitizerForOS(s.rules, os)
+	s.prompt = driver.ForOS(os).Prompt
+}
+
+// Clean returns the output of cmd without echo, trailing prompt, escape sequences and pager leftovers.
+// cmd is the command echoed by the device, empty if the device did not echo it.
+func (s *OutputSanitizer) Clean(output, cmd string) string {
+	return s.rule.clean(output, cmd, s.prompt)
+}
+
+// sanitizersForConfig compiles the sanitizer rules per OS. Rules of an OS inherit unset values from the default rules.
+func sanitizersForConfig(cfg *config.Config) (map[string]*sanitizer, error) {
+	defaults := &config.SanitizerConfig{}
+	for os, sc := range cfg.Sanitizers {
+		if strings.ToLower(os) == DefaultOS && sc != nil {
+			defaults = sc
+		}
+	}
+
+	s, err := newSanitizer(defaults, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid default sanitizer")
+	}
+	sanitizers := map[string]*sanitizer{DefaultOS: s}
+
+	for os, sc := range cfg.Sanitizers {
+		os = strings.ToLower(os)
+		if os == DefaultOS || sc == nil {
+			continue
+		}
+
+		s, err := newSanitizer(sc, defaults)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid sanitizer for %s", os)
+		}
+		sanitizers[os] = s
+	}
+
+	return sanitizers, nil
+}
+
+// newSanitizer compiles the rules of sc, unset values are taken from defaults (nil for the default rules themselves)
+func newSanitizer(sc, defaults *config.SanitizerConfig) (*sanitizer, error) {
+	patterns := append([]string{}, builtinDropLines...)
+	if defaults != nil {
+		patterns = append(patterns, defaults.DropLines...)
+	} else {
+		defaults = &config.SanitizerConfig{}
+	}
+	patterns = append(patterns, sc.DropLines...)
+
+	s := &sanitizer{
+		stripANSI:      boolOrDefault(sc.StripANSI, defaults.StripANSI),
+		stripBackspace: boolOrDefault(sc.StripBackspace, defaults.StripBackspace),
+		stripEcho:      boolOrDefault(sc.StripEcho, defaults.StripEcho),
+		stripPrompt:    boolOrDefault(sc.StripPrompt, defaults.StripPrompt),
+	}
+
+	for _, p := range patterns {
+		r, err := regexp.Compile(p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid drop_lines pattern %q", p)
+		}
+		s.dropLines = append(s.dropLines, r)
+	}
+
+	return s, nil
+}
+
+// boolOrDefault returns v, the default value if v is not set, or true if both are not set
+func boolOrDefault(v, def *bool) bool {
+	if v != nil {
+		return *v
+	}
+	if def != nil {
+		return *def
+	}
+
+	return true
+}
+
+// sanitizerForOS returns the rules for os, falling back to the default rules
+func sanitizerForOS(sanitizers map[string]*sanitizer, os string) *sanitizer {
+	if s, found := sanitizers[strings.ToLower(os)]; found {
+		return s
+	}
+
+	return sanitizers[DefaultOS]
+}
+
+// clean returns the output of cmd without echo, trailing prompt, escape sequences and pager leftovers
+func (s *sanitizer) clean(output, cmd string, prompt *regexp.Regexp) string {
+	if s.stripBackspace {
+		output = applyBackspaces(output)
+	}
+	if s.stripANSI {
+		output = ansiEscapeRegexp.ReplaceAllString(output, "")
+	}
+
+	lines := strings.Split(output, "\n")
+
+	if s.stripPrompt && len(lines) > 0 && prompt.MatchString(lines[len(lines)-1]) {
+		lines = lines[:len(lines)-1]
+	}
+
+	// blank and prompt lines before the echo are leftovers of the login or of previous commands.
+	// The echo is not looked for after the first line of output, output may end with the command as well.
+	if s.stripEcho && cmd != "" {
+		for i, line := range lines {
+			trimmed := strings.TrimSpace(line)
+			if strings.HasSuffix(trimmed, cmd) {
+				lines = lines[i+1:]
+				break
+			}
+			if trimmed != "" && !prompt.MatchString(line) {
+				break
+			}
+		}
+	}
+
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if !s.dropLine(line) {
+			result = append(result, line)
+		}
+	}
+
+	return strings.Join(result, "\n")
+}
+
+func (s *sanitizer) dropLine(line string) bool {
+	for _, r := range s.dropLines {
+		if r.MatchString(line) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// applyBackspaces removes every backspace together with the character it erases
+func applyBackspaces(s string) string {
+	if !strings.ContainsRune(s, '\b') {
+		return s
+	}
+
+	out := make([]rune, 0, len(s))
+	for _, r := range s {
+		if r != '\b' {
+			out = append(out, r)
+			continue
+		}
+		if len(out) > 0 && out[len(out)-1] != '\n' {
+			out = out[:len(out)-1]
+		}
+	}
+
+	return string(out)
+}
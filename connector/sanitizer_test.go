@@ -0,0 +1,39 @@
+package connector
+
+import (
+	"testing"
+
+	"github.com/shenjler/ssh_ping_exporter/config"
+)
+
+const ciscoHistory = `  terminal length 0
+  show version
+  show history`
+
+func TestCleanEcho(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		cmd    string
+		want   string
+	}{
+		{name: "echo", output: "show history\n" + ciscoHistory + "\nrtr1#", cmd: "show history", want: ciscoHistory},
+		{name: "leftovers before echo", output: "\nrtr1#\nshow history\n" + ciscoHistory + "\nrtr1#", cmd: "show history", want: ciscoHistory},
+		{name: "no echo", output: ciscoHistory + "\nrtr1#", cmd: "", want: ciscoHistory},
+		{name: "output ending with the command", output: ciscoHistory + "\nrtr1#", cmd: "show history", want: ciscoHistory},
+	}
+
+	s, err := NewOutputSanitizer(config.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetOS("IOSXE")
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := s.Clean(test.output, test.cmd); got != test.want {
+				t.Errorf("got %q, expected %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+// Package custom provides collectors declared in the config file: a command per OS and a regular expression or TextFSM template whose groups become labels and values
+package custom
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/shenjler/ssh_ping_exporter/collector"
+	"github.com/shenjler/ssh_ping_exporter/config"
+	"github.com/shenjler/ssh_ping_exporter/rpc"
+	"github.com/shenjler/ssh_ping_exporter/textfsm"
+)
+
+// Match modes
+const (
+	MatchLine  = "line"
+	MatchBlock = "block"
+)
+
+// DefaultOS is the command key used for OSes without a command of their own
+const DefaultOS = "default"
+
+type metric struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+	value     int
+	valueMap  map[string]float64
+	labels    []int
+}
+
+type customCollector struct {
+	name     string
+	commands map[string]string
+	regex    *regexp.Regexp
+	template *textfsm.Template
+	block    bool
+	metrics  []*metric
+}
+
+// NewCollector creates a collector from cfg. An error is returned if cfg is invalid.
+func NewCollector(cfg *config.CustomCollectorConfig) (collector.RPCCollector, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("custom collector without name")
+	}
+	if len(cfg.Commands) == 0 {
+		return nil, fmt.Errorf("custom collector %s: no commands", cfg.Name)
+	}
+
+	c := &customCollector{
+		name:     cfg.Name,
+		commands: make(map[string]string),
+	}
+
+	var err error
+	if cfg.Template != "" {
+		if cfg.Regex != "" {
+			return nil, fmt.Errorf("custom collector %s: regex and template are mutually exclusive", cfg.Name)
+		}
+		c.template, err = textfsm.ParseFile(cfg.Template)
+		if err != nil {
+			return nil, fmt.Errorf("custom collector %s: invalid template: %w", cfg.Name, err)
+		}
+	} else {
+		c.regex, err = regexp.Compile(cfg.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("custom collector %s: invalid regex: %w", cfg.Name, err)
+		}
+	}
+	for os, cmd := range cfg.Commands {
+		c.commands[strings.ToLower(os)] = cmd
+	}
+
+	switch cfg.Match {
+	case "", MatchLine:
+	case MatchBlock:
+		c.block = true
+	default:
+		return nil, fmt.Errorf("custom collector %s: invalid match mode %q", cfg.Name, cfg.Match)
+	}
+
+	if len(cfg.Metrics) == 0 {
+		return nil, fmt.Errorf("custom collector %s: no metrics", cfg.Name)
+	}
+	names := make(map[string]bool)
+	for _, m := range cfg.Metrics {
+		if names[m.Name] {
+			return nil, fmt.Errorf("custom collector %s: duplicate metric %s", cfg.Name, m.Name)
+		}
+		names[m.Name] = true
+
+		metric, err := c.newMetric(m)
+		if err != nil {
+			return nil, fmt.Errorf("custom collector %s: %w", cfg.Name, err)
+		}
+		c.metrics = append(c.metrics, metric)
+	}
+
+	return c, nil
+}
+
+func (c *customCollector) newMetric(cfg *config.CustomMetricConfig) (*metric, error) {
+	if !model.IsValidMetricName(model.LabelValue(cfg.Name)) {
+		return nil, fmt.Errorf("invalid metric name %q", cfg.Name)
+	}
+
+	m := &metric{valueMap: cfg.ValueMap}
+
+	switch cfg.Type {
+	case "", "gauge":
+		m.valueType = prometheus.GaugeValue
+	case "counter":
+		m.valueType = prometheus.CounterValue
+	default:
+		return nil, fmt.Errorf("metric %s: invalid type %q", cfg.Name, cfg.Type)
+	}
+
+	m.value = c.group(cfg.Value)
+	if cfg.Value == "" || m.value < 0 {
+		return nil, fmt.Errorf("metric %s: value group %q not found in %s", cfg.Name, cfg.Value, c.source())
+	}
+
+	labels := []string{"target"}
+	for _, l := range cfg.Labels {
+		i := c.group(l)
+		if l == "" || i < 0 {
+			return nil, fmt.Errorf("metric %s: label group %q not found in %s", cfg.Name, l, c.source())
+		}
+		if !model.LabelName(l).IsValid() || l == "target" {
+			return nil, fmt.Errorf("metric %s: invalid label name %q", cfg.Name, l)
+		}
+		m.labels = append(m.labels, i)
+		labels = append(labels, l)
+	}
+
+	help := cfg.Help
+	if help == "" {
+		help = "Custom metric " + cfg.Name + " of collector " + c.name
+	}
+	m.desc = prometheus.NewDesc(cfg.Name, help, labels, nil)
+
+	return m, nil
+}
+
+// Name returns the name of the collector
+func (c *customCollector) Name() string {
+	return c.name
+}
+
+// Describe describes the metrics
+func (c *customCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range c.metrics {
+		ch <- m.desc
+	}
+}
+
+// Collect runs the command of the OS and exports a metric per match
+func (c *customCollector) Collect(sc *collector.ScrapeContext, client *rpc.Client, ch chan<- prometheus.Metric) error {
+	cmd, found := c.commands[strings.ToLower(client.OSType)]
+	if !found {
+		cmd, found = c.commands[DefaultOS]
+	}
+	if !found {
+		sc.Logger.Debugf("%s: no command (%s)", c.name, client.OSType)
+		return nil
+	}
+
+	out, err := client.RunCommand(sc.Context, cmd)
+	if err != nil {
+		return err
+	}
+
+	matches, err := c.matches(cmd, out)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range c.metrics {
+		seen := make(map[string]bool)
+		for _, match := range matches {
+			value, ok := m.valueOf(match[m.value])
+			if !ok {
+				sc.Logger.Debugf("%s: value %q is not a number", c.name, match[m.value])
+				continue
+			}
+
+			l := sc.LabelValues()
+			for _, i := range m.labels {
+				l = append(l, match[i])
+			}
+
+			key := strings.Join(l, "\x00")
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			ch <- prometheus.MustNewConstMetric(m.desc, m.valueType, value, l...)
+		}
+	}
+
+	return nil
+}
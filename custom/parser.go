@@ -0,0 +1,85 @@
+package custom
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/shenjler/ssh_ping_exporter/driver"
+)
+
+// group returns the index of the regex group or template value name in the matches, -1 if there is none
+func (c *customCollector) group(name string) int {
+	if c.template == nil {
+		return c.regex.SubexpIndex(name)
+	}
+
+	for i, v := range c.template.Header() {
+		if v == name {
+			return i + 1
+		}
+	}
+
+	return -1
+}
+
+// source describes where groups are looked up for errors
+func (c *customCollector) source() string {
+	if c.template != nil {
+		return "template"
+	}
+
+	return "regex"
+}
+
+// matches returns the submatches of the regex per line or in the whole output, or the records of the template.
+// Like submatches, records start with an empty element followed by the values in template order.
+// Output the regex does not match at all is a parse error.
+func (c *customCollector) matches(cmd, output string) ([][]string, error) {
+	if c.template != nil {
+		records, err := c.template.ParseText(output)
+		if err != nil {
+			return nil, &driver.ParseError{Command: cmd, Err: err}
+		}
+
+		result := make([][]string, len(records))
+		for i, r := range records {
+			result[i] = []string{""}
+			for _, name := range c.template.Header() {
+				result[i] = append(result[i], r.String(name))
+			}
+		}
+
+		return result, nil
+	}
+
+	var result [][]string
+	if c.block {
+		result = c.regex.FindAllStringSubmatch(output, -1)
+	} else {
+		for _, line := range strings.Split(output, "\n") {
+			if match := c.regex.FindStringSubmatch(line); match != nil {
+				result = append(result, match)
+			}
+		}
+	}
+	if len(result) == 0 {
+		return nil, &driver.ParseError{Command: cmd, Err: errors.New("regex did not match")}
+	}
+
+	return result, nil
+}
+
+// valueOf translates s by the value map or parses it as number
+func (m *metric) valueOf(s string) (float64, bool) {
+	if v, found := m.valueMap[s]; found {
+		return v, true
+	}
+
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return v, true
+}
@@ -0,0 +1,112 @@
+package custom
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shenjler/ssh_ping_exporter/config"
+	"github.com/shenjler/ssh_ping_exporter/driver"
+)
+
+const ntpAssociations = `  address         ref clock       st   when   poll reach  delay  offset   disp
+*~10.0.0.1        .GPS.            1     33     64   377  1.213  -0.052  0.981
++~10.0.0.2        10.0.0.1         2     12     64   377  2.004   0.113  1.002
+ * sys.peer, # selected, + candidate, - outlyer, x falseticker, ~ configured`
+
+func newTestCollector(t *testing.T, regex, match string) *customCollector {
+	t.Helper()
+
+	c, err := NewCollector(&config.CustomCollectorConfig{
+		Name:     "ntp",
+		Commands: map[string]string{DefaultOS: "show ntp associations"},
+		Regex:    regex,
+		Match:    match,
+		Metrics: []*config.CustomMetricConfig{
+			{Name: "pccw_ntp_peer_stratum", Value: "stratum", Labels: []string{"peer"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c.(*customCollector)
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		regex  string
+		match  string
+		output string
+		peers  []string
+		err    bool
+	}{
+		{
+			name:   "line",
+			regex:  `^[*+#-]?~?(?P<peer>\d+\.\d+\.\d+\.\d+)\s+\S+\s+(?P<stratum>\d+)`,
+			output: ntpAssociations,
+			peers:  []string{"10.0.0.1", "10.0.0.2"},
+		},
+		{
+			name:   "block",
+			regex:  `(?m)^[*+#-]?~?(?P<peer>\d+\.\d+\.\d+\.\d+)\s+\S+\s+(?P<stratum>\d+)`,
+			match:  MatchBlock,
+			output: ntpAssociations,
+			peers:  []string{"10.0.0.1", "10.0.0.2"},
+		},
+		{
+			name:   "no match",
+			regex:  `^[*+#-]?~?(?P<peer>\d+\.\d+\.\d+\.\d+)\s+\S+\s+(?P<stratum>\d+)`,
+			output: "% NTP is not enabled.",
+			err:    true,
+		},
+		{
+			name:   "no match in block",
+			regex:  `(?m)^[*+#-]?~?(?P<peer>\d+\.\d+\.\d+\.\d+)\s+\S+\s+(?P<stratum>\d+)`,
+			match:  MatchBlock,
+			output: "",
+			err:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := newTestCollector(t, test.regex, test.match)
+
+			matches, err := c.matches("show ntp associations", test.output)
+			if test.err {
+				var pe *driver.ParseError
+				if !errors.As(err, &pe) {
+					t.Fatalf("expected a parse error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			peer := c.group("peer")
+			if len(matches) != len(test.peers) {
+				t.Fatalf("got %d matches, expected %d", len(matches), len(test.peers))
+			}
+			for i, m := range matches {
+				if m[peer] != test.peers[i] {
+					t.Errorf("match %d: got peer %q, expected %q", i, m[peer], test.peers[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNewCollectorDuplicateMetric(t *testing.T) {
+	metric := &config.CustomMetricConfig{Name: "pccw_ntp_peer_stratum", Value: "stratum"}
+	_, err := NewCollector(&config.CustomCollectorConfig{
+		Name:     "ntp",
+		Commands: map[string]string{DefaultOS: "show ntp associations"},
+		Regex:    `(?P<stratum>\d+)`,
+		Metrics:  []*config.CustomMetricConfig{metric, metric},
+	})
+	if err == nil {
+		t.Errorf("expected an error for a duplicate metric")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/shenjler/ssh_ping_exporter/config"
 	"github.com/shenjler/ssh_ping_exporter/connector"
+	"github.com/shenjler/ssh_ping_exporter/driver"
 )
 
 func devicesForConfig(cfg *config.Config) ([]*connector.Device, error) {
@@ -28,6 +29,10 @@ func deviceFromDeviceConfig(device *config.DeviceConfig, cfg *config.Config) (*c
 		return nil, errors.Wrapf(err, "could not initialize config for device %s", device.Host)
 	}
 
+	if device.OS != nil && !driver.Known(*device.OS) {
+		return nil, errors.Errorf("unknown OS %q configured for device %s", *device.OS, device.Host)
+	}
+
 	port := "22"
 	host := device.Host
 	if strings.Contains(host, ":") {
@@ -36,20 +41,50 @@ func deviceFromDeviceConfig(device *config.DeviceConfig, cfg *config.Config) (*c
 		port = d[1]
 	}
 
-	return &connector.Device{
-		Host:         host,
-		Port:         port,
-		Auth:         auth,
-		DeviceConfig: device,
-	}, nil
+	d := &connector.Device{
+		Host:           host,
+		Port:           port,
+		Username:       usernameForDevice(device, cfg),
+		Auth:           auth,
+		DeviceConfig:   device,
+		EnablePassword: enablePasswordForDevice(device, cfg),
+	}
+
+	err = connector.CheckDevice(d, cfg)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not initialize config for device %s", device.Host)
+	}
+
+	return d, nil
 }
 
-func authForDevice(device *config.DeviceConfig, cfg *config.Config) (connector.AuthMethod, error) {
-	user := cfg.Username
+func usernameForDevice(device *config.DeviceConfig, cfg *config.Config) string {
 	if device.Username != nil {
-		user = *device.Username
+		return *device.Username
 	}
 
+	return cfg.Username
+}
+
+func privilegedForDevice(device *config.DeviceConfig, cfg *config.Config) bool {
+	if device.Privileged != nil {
+		return *device.Privileged
+	}
+
+	return cfg.Privileged
+}
+
+func enablePasswordForDevice(device *config.DeviceConfig, cfg *config.Config) string {
+	if device.EnablePassword != nil {
+		return *device.EnablePassword
+	}
+
+	return cfg.EnablePassword
+}
+
+func authForDevice(device *config.DeviceConfig, cfg *config.Config) (connector.AuthMethod, error) {
+	user := usernameForDevice(device, cfg)
+
 	if device.KeyFile != nil {
 		return authForKeyFile(user, *device.KeyFile)
 	}
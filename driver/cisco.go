@@ -0,0 +1,179 @@
+package driver
+
+import (
+	"regexp"
+)
+
+var (
+	ciscoPrompt    = regexp.MustCompile(`(?m)^[\w.@:/()-]+[#>][ \t]*\z`)
+	enablePrompt   = regexp.MustCompile(`(?m)^[ \t]*Password:[ \t]*\z`)
+	ciscoAllowlist = []string{
+		`show ` + argsPattern + filterPattern,
+		`terminal (?:length|width) \d+`,
+		`enable`,
+		`ping (?:vrf [\w.-]+ )?` + hostPattern + `(?: repeat \d+)?`,
+	}
+	ciscoHostname = regexp.MustCompile(`(?m)^(\S+) uptime is`)
+	ciscoModel    = regexp.MustCompile(`(?m)^cisco (\S+) .*processor`)
+	ciscoPing     = &PingCommand{
+		Command: func(dest string) string { return "ping " + dest + " repeat 3" },
+		Parse:   parseCiscoPing,
+	}
+	ciscoMemory = &MemoryCommand{Command: "show process memory", Parse: parseCiscoMemory}
+	ciscoCPU    = &CPUCommand{Command: "show process cpu", Parse: parseCiscoCPU}
+	xeDevRegexp = regexp.MustCompile(`\S(\d+)/(\d+)/(\d+)`)
+)
+
+func init() {
+	register(&Driver{
+		OS:              IOSXE,
+		Prompt:          ciscoPrompt,
+		PagerDisable:    "terminal length 0",
+		Privilege:       "enable",
+		PrivilegePrompt: enablePrompt,
+		Allowlist:       ciscoAllowlist,
+		Info: InfoPatterns{
+			Version:  regexp.MustCompile(`(?m)^Cisco IOS[ -]XE Software, Version (\S+)`),
+			Model:    ciscoModel,
+			Hostname: ciscoHostname,
+		},
+		BGP: &BGPCommand{Command: "show bgp all summary", Parse: parseCiscoBGP},
+		Environment: &EnvironmentCommand{
+			Command: "show environment",
+			Parse: environmentParser(
+				regexp.MustCompile(`\s*(\w\w)\s*Temp: (\w+)\s+\w+\s+(\d+) Celsius`),
+				regexp.MustCompile(`\s*(\w\w)\s*PEM (\w+)\s+(\w+)\s+\d*\s[\s\w]*`)),
+		},
+		Facts: &FactsCommands{
+			Version: &VersionCommand{Command: "show version", Parse: versionParser(IOSXE, regexp.MustCompile(`^.*, Version (.+) -.*$`))},
+			Memory:  ciscoMemory,
+			CPU:     ciscoCPU,
+		},
+		Interfaces: &InterfacesCommand{
+			Command: "show interface",
+			Parse:   parseCiscoInterfaces,
+			Vlans:   &InterfacesCommand{Command: "show vlans", Parse: parseCiscoVlans},
+		},
+		Optics: &OpticsCommands{
+			Command:         "show interfaces stats | exclude disabled",
+			ParseInterfaces: parseCiscoInterfaceNames,
+			Transceiver: func(iface string) (string, bool) {
+				matches := xeDevRegexp.FindStringSubmatch(iface)
+				if matches == nil {
+					return "", false
+				}
+				return "show hw-module subslot " + matches[1] + "/" + matches[2] + " transceiver " + matches[3] + " status", true
+			},
+			ParseTransceiver: transceiverParser(regexp.MustCompile(`\s+Transceiver Tx power\s+= ((?:-)?\d+\.\d+).*\s*Transceiver Rx optical power\s+= ((?:-)?\d+\.\d+).*`)),
+		},
+		Ping: ciscoPing,
+	})
+
+	register(&Driver{
+		OS:              IOS,
+		Prompt:          ciscoPrompt,
+		PagerDisable:    "terminal length 0",
+		Privilege:       "enable",
+		PrivilegePrompt: enablePrompt,
+		Allowlist:       ciscoAllowlist,
+		Info: InfoPatterns{
+			Version:  regexp.MustCompile(`(?m)^Cisco IOS Software.*, Version ([^,\s]+)`),
+			Model:    ciscoModel,
+			Hostname: ciscoHostname,
+		},
+		Environment: &EnvironmentCommand{
+			Command: "show environment",
+			Parse: environmentParser(
+				regexp.MustCompile(`^(\d+)\s+(air \w+(?: +\w+)?)\s+(\d+)C \(.*\)\s+\w+$`),
+				regexp.MustCompile(`^(\w+)\s+.+\s+(AC) \w+\s+(\w+)\s+\w+\s+.+\s+.+$`)),
+		},
+		Facts: &FactsCommands{
+			Version: &VersionCommand{Command: "show version", Parse: versionParser(IOS, regexp.MustCompile(`^.*, Version (.+),.*$`))},
+			Memory:  ciscoMemory,
+			CPU:     ciscoCPU,
+		},
+		Interfaces: &InterfacesCommand{Command: "show interface", Parse: parseCiscoInterfaces},
+		Optics: &OpticsCommands{
+			Command:         "show interfaces stats | exclude disabled",
+			ParseInterfaces: parseCiscoInterfaceNames,
+			Transceiver: func(iface string) (string, bool) {
+				return "show interfaces " + iface + " transceiver", true
+			},
+			ParseTransceiver: transceiverParser(regexp.MustCompile(`\S+\s+(?:(?:-)?\d+\.\d+)\s+(?:(?:-)?\d+\.\d+)\s+((?:-)?\d+\.\d+)\s+((?:-)?\d+\.\d+)\s*`)),
+		},
+		Ping: ciscoPing,
+	})
+
+	register(&Driver{
+		OS:           NXOS,
+		Prompt:       ciscoPrompt,
+		PagerDisable: "terminal length 0",
+		Allowlist:    ciscoAllowlist,
+		Info: InfoPatterns{
+			Version:  regexp.MustCompile(`(?m)^\s*(?:NXOS|system):\s+version (\S+)`),
+			Model:    regexp.MustCompile(`(?m)^\s*cisco (.+?) [Cc]hassis`),
+			Hostname: regexp.MustCompile(`(?m)^\s*Device name: (\S+)`),
+		},
+		BGP: &BGPCommand{
+			Command:  "show bgp all summary | json",
+			Parse:    parseNXOSBGP,
+			Fallback: &BGPCommand{Command: "show bgp all summary", Parse: parseCiscoBGP},
+		},
+		Environment: &EnvironmentCommand{
+			Command: "show environment | json",
+			Parse:   parseNXOSEnvironment,
+			Fallback: &EnvironmentCommand{
+				Command: "show environment",
+				Parse: environmentParser(
+					regexp.MustCompile(`^(\d+)\s+(.+)\s+\d\d?\s+\d\d?\s+(\d\d?)\s+\w+\s*$`),
+					regexp.MustCompile(`^(\d+)\s+.+\s+(AC)\s+.+\s+.+\s+(\w+)\s*$`)),
+			},
+		},
+		Facts: &FactsCommands{
+			Version: &VersionCommand{Command: "show version", Parse: versionParser(NXOS, regexp.MustCompile(`^\s+NXOS: version (.*)$`))},
+		},
+		Interfaces: &InterfacesCommand{
+			Command:  "show interface | json",
+			Parse:    parseNXOSInterfaces,
+			Fallback: &InterfacesCommand{Command: "show interface", Parse: parseCiscoInterfaces},
+		},
+		Optics: &OpticsCommands{
+			Structured:      &OpticsTableCommand{Command: "show interface transceiver details | json", Parse: parseNXOSOptics},
+			Command:         "show interfaces stats | exclude disabled",
+			ParseInterfaces: parseCiscoInterfaceNames,
+			Transceiver: func(iface string) (string, bool) {
+				return "show interface " + iface + " transceiver details", true
+			},
+			ParseTransceiver: transceiverParser(regexp.MustCompile(`\s*Tx Power\s*((?:-)?\d+\.\d+).*\s*Rx Power\s*((?:-)?\d+\.\d+).*`)),
+		},
+		Ping: ciscoPing,
+	})
+
+	register(&Driver{
+		OS:              EOS,
+		Prompt:          ciscoPrompt,
+		PagerDisable:    "terminal length 0",
+		Privilege:       "enable",
+		PrivilegePrompt: enablePrompt,
+		Allowlist:       ciscoAllowlist,
+		Info: InfoPatterns{
+			Version:  regexp.MustCompile(`(?m)^Software image version: (\S+)`),
+			Model:    regexp.MustCompile(`(?m)^Arista (\S+)`),
+			Hostname: regexp.MustCompile(`(?m)^Hostname: (\S+)`),
+		},
+		BGP: &BGPCommand{Command: "show ip bgp summary vrf all | json", Parse: parseEOSBGP},
+		Environment: &EnvironmentCommand{
+			Command: "show environment temperature | json",
+			Parse:   parseEOSTemperature,
+			More:    []*EnvironmentCommand{{Command: "show environment power | json", Parse: parseEOSPower}},
+		},
+		Interfaces: &InterfacesCommand{Command: "show interfaces | json", Parse: parseEOSInterfaces},
+		Optics: &OpticsCommands{
+			Structured: &OpticsTableCommand{Command: "show interfaces transceiver | json", Parse: parseEOSOptics},
+		},
+		Ping: &PingCommand{
+			Command: func(dest string) string { return "ping " + dest + " repeat 3" },
+			Parse:   parseUnixPing,
+		},
+	})
+}
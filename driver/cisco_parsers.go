@@ -0,0 +1,332 @@
+package driver
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/shenjler/ssh_ping_exporter/util"
+)
+
+var (
+	ciscoNeighborRegexp  = regexp.MustCompile(`(\S+)\s+\d\s+(\d+)\s+(\d+)\s+(\d+)\s+\d+\s+\d+\s+\d+\s+\S+\s+(\S+)\s*`)
+	ciscoNeighborHeader  = regexp.MustCompile(`(?m)^Neighbor\s+V\s+AS\s`)
+	ciscoBGPSummary      = regexp.MustCompile(`(?m)^(?:BGP router identifier \S+, local AS number|% BGP not active)`)
+	ciscoMemoryRegexp    = regexp.MustCompile(`^\s*(\S*) Pool Total:\s*(\d+) Used:\s*(\d+) Free:\s*(\d+)\s*$`)
+	ciscoCPURegexp       = regexp.MustCompile(`^\s*CPU utilization for five seconds: (\d+)%\/(\d+)%; one minute: (\d+)%; five minutes: (\d+)%.*$`)
+	ciscoIfNameRegexp    = regexp.MustCompile(`^([a-zA-Z0-9\/\.-]+)\s*$`)
+	ciscoPingDestRegexp  = regexp.MustCompile(`(?m)^Sending \d+, \d+-byte ICMP Echos to (\S+),`)
+	ciscoPingRateRegexp  = regexp.MustCompile(`(?m)^Success rate is (\d+) percent \(\d+/\d+\)(?:, round-trip min/avg/max = (\d+)/(\d+)/(\d+) ms)?`)
+	ciscoVlanNameRegexp  = regexp.MustCompile(`^([a-zA-Z0-9\/-]+\.[a-zA-Z0-9\/-]+) \(:?\d+\).*$`)
+	ciscoVlanInputRegex  = regexp.MustCompile(`^\s+Total \d+ packets, (\d+) bytes input.*$`)
+	ciscoVlanOutputRegex = regexp.MustCompile(`^\s+Total \d+ packets, (\d+) bytes output.*$`)
+)
+
+// parseCiscoBGP parses 'show bgp all summary' and tries to find bgp sessions with related data
+// Output without neighbor table is only valid if it is empty or has the summary header, a table without a neighbor is never valid.
+func parseCiscoBGP(output string) ([]BgpSession, error) {
+	items := []BgpSession{}
+
+	matches := ciscoNeighborRegexp.FindAllStringSubmatch(output, -1)
+	if matches == nil {
+		err := checkBGPWithoutPeers(output, ciscoNeighborHeader, ciscoBGPSummary)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, match := range matches {
+		pref := util.Str2float64(match[5])
+		up := true
+		if pref < 0 {
+			pref = 0
+			up = false
+		}
+
+		item := BgpSession{
+			IP:               match[1],
+			Asn:              match[2],
+			InputMessages:    util.Str2float64(match[3]),
+			OutputMessages:   util.Str2float64(match[4]),
+			Up:               up,
+			ReceivedPrefixes: pref,
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// checkBGPWithoutPeers returns an error unless output without peers is empty or a summary without peer table
+func checkBGPWithoutPeers(output string, tableHeader, summaryHeader *regexp.Regexp) error {
+	switch {
+	case strings.TrimSpace(output) == "":
+		return nil
+	case tableHeader.MatchString(output):
+		return errors.New("no peer found in the peer table")
+	case summaryHeader.MatchString(output):
+		return nil
+	default:
+		return errors.New("no BGP summary found")
+	}
+}
+
+// environmentParser returns a parser finding temperature and power supply lines. Output without such lines is only valid if it is empty.
+func environmentParser(tempRegexp, powerRegexp *regexp.Regexp) func(output string) ([]EnvironmentItem, error) {
+	return func(output string) ([]EnvironmentItem, error) {
+		items := []EnvironmentItem{}
+		lines := strings.Split(output, "\n")
+		for _, line := range lines {
+			if matches := tempRegexp.FindStringSubmatch(line); matches != nil {
+				x := EnvironmentItem{
+					Name:        strings.TrimSpace(matches[1] + " " + matches[2]),
+					IsTemp:      true,
+					Temperature: util.Str2float64(matches[3]),
+				}
+				items = append(items, x)
+			} else if matches := powerRegexp.FindStringSubmatch(line); matches != nil {
+				ok := matches[3] == "Normal" || matches[3] == "good" || matches[3] == "ok"
+				x := EnvironmentItem{
+					Name:   strings.TrimSpace(matches[1] + " " + matches[2]),
+					IsTemp: false,
+					OK:     ok,
+					Status: matches[3],
+				}
+				items = append(items, x)
+			}
+		}
+		if len(items) == 0 && strings.TrimSpace(output) != "" {
+			return nil, errors.New("no temperature or power supply found")
+		}
+		return items, nil
+	}
+}
+
+// versionParser returns a parser finding the version number of the running OS, the version is prefixed with os
+func versionParser(os string, versionRegexp *regexp.Regexp) func(output string) (VersionFact, error) {
+	return func(output string) (VersionFact, error) {
+		lines := strings.Split(output, "\n")
+		for _, line := range lines {
+			matches := versionRegexp.FindStringSubmatch(line)
+			if matches == nil {
+				continue
+			}
+			return VersionFact{Version: os + "-" + matches[1]}, nil
+		}
+		return VersionFact{}, errors.New("Version string not found")
+	}
+}
+
+// parseCiscoMemory parses 'show process memory' and tries to find current memory usage
+func parseCiscoMemory(output string) ([]MemoryFact, error) {
+	items := []MemoryFact{}
+	lines := strings.Split(output, "\n")
+	for _, line := range lines {
+		matches := ciscoMemoryRegexp.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		item := MemoryFact{
+			Type:  matches[1],
+			Total: util.Str2float64(matches[2]),
+			Used:  util.Str2float64(matches[3]),
+			Free:  util.Str2float64(matches[4]),
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// parseCiscoCPU parses 'show process cpu' and tries to find current CPU utilization
+func parseCiscoCPU(output string) (CPUFact, error) {
+	lines := strings.Split(output, "\n")
+	for _, line := range lines {
+		matches := ciscoCPURegexp.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		return CPUFact{
+			FiveSeconds: util.Str2float64(matches[1]),
+			Interrupts:  util.Str2float64(matches[2]),
+			OneMinute:   util.Str2float64(matches[3]),
+			FiveMinutes: util.Str2float64(matches[4]),
+		}, nil
+	}
+	return CPUFact{}, errors.New("CPU utilization not found")
+}
+
+// parseCiscoInterfaceNames parses 'show interfaces stats' and returns list of interface names
+func parseCiscoInterfaceNames(output string) ([]string, error) {
+	var items []string
+	lines := strings.Split(output, "\n")
+	for _, line := range lines {
+		matches := ciscoIfNameRegexp.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		items = append(items, matches[1])
+	}
+	return items, nil
+}
+
+// transceiverParser returns a parser finding tx/rx power of an interface, the first submatch is tx, the second rx
+func transceiverParser(transceiverRegexp *regexp.Regexp) func(output string) (Optics, error) {
+	return func(output string) (Optics, error) {
+		matches := transceiverRegexp.FindStringSubmatch(output)
+		if matches == nil {
+			return Optics{}, errors.New("Transceiver not found")
+		}
+		return Optics{
+			TxPower: util.Str2float64(matches[1]),
+			RxPower: util.Str2float64(matches[2]),
+		}, nil
+	}
+}
+
+// parseCiscoInterfaces parses 'show interface' and tries to find interfaces with related stats
+func parseCiscoInterfaces(output string) ([]Interface, error) {
+	items := []Interface{}
+	txNXOS := regexp.MustCompile(`^\s+TX$`) // NX OS
+	newIfRegexp := regexp.MustCompile(`(?:^!?(?: |admin).*$|^$)`)
+	macRegexp := regexp.MustCompile(`^\s+Hardware(?: is|:) .+, address(?: is|:) (.*) \(.*\)$`)
+	deviceNameRegexp := regexp.MustCompile(`^([a-zA-Z0-9\/\.-]+) is.*$`)
+	adminStatusRegexp := regexp.MustCompile(`^.+ is (administratively)?\s*(up|down).*, line protocol is.*$`)
+	adminStatusNXOSRegexp := regexp.MustCompile(`^\S+ is (up|down)(?:\s|,)?(\(Administratively down\))?.*$`)
+	descRegexp := regexp.MustCompile(`^\s+Description: (.*)$`)
+	dropsRegexp := regexp.MustCompile(`^\s+Input queue: \d+\/\d+\/(\d+)\/\d+ .+ Total output drops: (\d+)$`)
+	multiBroadNXOS := regexp.MustCompile(`^.* (\d+) multicast packets\s+(\d+) broadcast packets$`)               // NX OS
+	multiBroadIOSXE := regexp.MustCompile(`^\s+Received\s+(\d+)\sbroadcasts \((\d+) (?:IP\s)?multicast(?:s)?\)`) // IOS XE
+	multiBroadIOS := regexp.MustCompile(`^\s*Received (\d+) broadcasts.*$`)                                      // IOS
+	inputBytesRegexp := regexp.MustCompile(`^\s+\d+ (?:packets input,|input packets)\s+(\d+) bytes.*$`)
+	outputBytesRegexp := regexp.MustCompile(`^\s+\d+ (?:packets output,|output packets)\s+(\d+) bytes.*$`)
+	inputErrorsRegexp := regexp.MustCompile(`^\s+(\d+) input error(?:s,)? .*$`)
+	outputErrorsRegexp := regexp.MustCompile(`^\s+(\d+) output error(?:s,)? .*$`)
+	speedRegexp := regexp.MustCompile(`^\s+(.*)-duplex,\s(\d+) ((\wb)/s).*$`)
+
+	isRx := true
+	current := Interface{}
+	lines := strings.Split(output, "\n")
+	for _, line := range lines {
+		if !newIfRegexp.MatchString(line) {
+			if current != (Interface{}) {
+				items = append(items, current)
+			}
+			matches := deviceNameRegexp.FindStringSubmatch(line)
+			if matches == nil {
+				continue
+			}
+			current = Interface{
+				Name: matches[1],
+			}
+			isRx = true
+		}
+		if current == (Interface{}) {
+			continue
+		}
+
+		if matches := adminStatusRegexp.FindStringSubmatch(line); matches != nil {
+			if matches[1] == "" {
+				current.AdminStatus = "up"
+			} else {
+				current.AdminStatus = "down"
+			}
+			current.OperStatus = matches[2]
+		} else if matches := adminStatusNXOSRegexp.FindStringSubmatch(line); matches != nil {
+			if matches[2] == "" {
+				current.AdminStatus = "up"
+			} else {
+				current.AdminStatus = "down"
+			}
+			current.OperStatus = matches[1]
+		} else if matches := descRegexp.FindStringSubmatch(line); matches != nil {
+			current.Description = matches[1]
+		} else if matches := macRegexp.FindStringSubmatch(line); matches != nil {
+			current.MacAddress = matches[1]
+		} else if matches := dropsRegexp.FindStringSubmatch(line); matches != nil {
+			current.InputDrops = util.Str2float64(matches[1])
+			current.OutputDrops = util.Str2float64(matches[2])
+		} else if matches := inputBytesRegexp.FindStringSubmatch(line); matches != nil {
+			current.InputBytes = util.Str2float64(matches[1])
+		} else if matches := outputBytesRegexp.FindStringSubmatch(line); matches != nil {
+			current.OutputBytes = util.Str2float64(matches[1])
+		} else if matches := inputErrorsRegexp.FindStringSubmatch(line); matches != nil {
+			current.InputErrors = util.Str2float64(matches[1])
+		} else if matches := outputErrorsRegexp.FindStringSubmatch(line); matches != nil {
+			current.OutputErrors = util.Str2float64(matches[1])
+		} else if matches := speedRegexp.FindStringSubmatch(line); matches != nil {
+			current.Speed = matches[2] + " " + matches[3]
+		} else if matches := txNXOS.FindStringSubmatch(line); matches != nil {
+			isRx = false
+		} else if matches := multiBroadNXOS.FindStringSubmatch(line); matches != nil {
+			if isRx {
+				current.InputMulticast = util.Str2float64(matches[1])
+				current.InputBroadcast = util.Str2float64(matches[2])
+			}
+		} else if matches := multiBroadIOSXE.FindStringSubmatch(line); matches != nil {
+			current.InputBroadcast = util.Str2float64(matches[1])
+			current.InputMulticast = util.Str2float64(matches[2])
+		} else if matches := multiBroadIOS.FindStringSubmatch(line); matches != nil {
+			current.InputBroadcast = util.Str2float64(matches[1])
+		}
+	}
+	return appendLastInterface(items, current, output)
+}
+
+// parseCiscoVlans parses 'show vlans' and tries to find vlans with related traffic stats
+func parseCiscoVlans(output string) ([]Interface, error) {
+	items := []Interface{}
+	current := Interface{}
+	lines := strings.Split(output, "\n")
+	for _, line := range lines {
+		if matches := ciscoVlanNameRegexp.FindStringSubmatch(line); matches != nil {
+			if current != (Interface{}) {
+				items = append(items, current)
+			}
+			current = Interface{
+				Name: matches[1],
+			}
+		}
+		if current == (Interface{}) {
+			continue
+		}
+		if matches := ciscoVlanInputRegex.FindStringSubmatch(line); matches != nil {
+			current.InputBytes = util.Str2float64(matches[1])
+		} else if matches := ciscoVlanOutputRegex.FindStringSubmatch(line); matches != nil {
+			current.OutputBytes = util.Str2float64(matches[1])
+		}
+	}
+	return appendLastInterface(items, current, output)
+}
+
+// appendLastInterface appends the interface parsed last unless no header matched.
+// Output without any interface is only valid if it is empty.
+func appendLastInterface(items []Interface, current Interface, output string) ([]Interface, error) {
+	if current != (Interface{}) {
+		items = append(items, current)
+	}
+	if len(items) == 0 && strings.TrimSpace(output) != "" {
+		return nil, errors.New("no interface found")
+	}
+	return items, nil
+}
+
+// parseCiscoPing parses the output of a Cisco ping. The loss is derived from the success rate.
+func parseCiscoPing(output string) (Icmp, error) {
+	current := Icmp{}
+	if matches := ciscoPingDestRegexp.FindStringSubmatch(output); matches != nil {
+		current.Target = matches[1]
+	}
+
+	matches := ciscoPingRateRegexp.FindStringSubmatch(output)
+	if matches == nil {
+		return current, errors.New("Success rate not found")
+	}
+	current.PacketLoss = 100 - util.Str2float64(matches[1])
+	if current.PacketLoss == 100 {
+		current.PingStatus = "down"
+	} else {
+		current.PingStatus = "up"
+		current.RttMin = util.Str2float64(matches[2])
+		current.RttAvg = util.Str2float64(matches[3])
+		current.RttMax = util.Str2float64(matches[4])
+	}
+	return current, nil
+}
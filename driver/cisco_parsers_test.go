@@ -0,0 +1,157 @@
+package driver
+
+import (
+	"regexp"
+	"testing"
+)
+
+const ciscoBGPSummaryHeader = `For address family: IPv4 Unicast
+BGP router identifier 10.255.0.1, local AS number 65000
+BGP table version is 42, main routing table version 42
+`
+
+func TestParseCiscoBGP(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		sessions int
+		wantErr  bool
+	}{
+		{
+			name: "peers",
+			output: ciscoBGPSummaryHeader + `
+Neighbor        V           AS MsgRcvd MsgSent   TblVer  InQ OutQ Up/Down  State/PfxRcd
+10.0.0.2        4        65001    1234    1235       42    0    0 1d02h           12
+10.0.0.6        4        65002       0       0        1    0    0 never    Idle`,
+			sessions: 2,
+		},
+		{name: "no address family", output: "\n"},
+		{name: "no peers", output: ciscoBGPSummaryHeader},
+		{name: "not active", output: "% BGP not active\n"},
+		{
+			name: "unknown peer format",
+			output: ciscoBGPSummaryHeader + `
+Neighbor        V           AS MsgRcvd MsgSent   TblVer  InQ OutQ Up/Down  State/PfxRcd
+10.0.0.2        4        65001    1234    1235       42    0    0    1d02h`,
+			wantErr: true,
+		},
+		{name: "unknown output", output: "% Invalid input detected at '^' marker.\n", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			items, err := parseCiscoBGP(test.output)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", items)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(items) != test.sessions {
+				t.Errorf("got %d sessions, expected %d", len(items), test.sessions)
+			}
+		})
+	}
+}
+
+func TestEnvironmentParser(t *testing.T) {
+	parse := environmentParser(
+		regexp.MustCompile(`\s*(\w\w)\s*Temp: (\w+)\s+\w+\s+(\d+) Celsius`),
+		regexp.MustCompile(`\s*(\w\w)\s*PEM (\w+)\s+(\w+)\s+\d*\s[\s\w]*`))
+
+	items, err := parse(` Slot    Sensor       Current State       Reading
+ ----    ------       -------------       -------
+ P0    PEM Iout       Normal              5 A
+ R0    Temp: Inlet    Normal              31 Celsius`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || !items[1].IsTemp || items[1].Temperature != 31 || !items[0].OK {
+		t.Errorf("unexpected items %+v", items)
+	}
+
+	items, err = parse("")
+	if err != nil || len(items) != 0 {
+		t.Errorf("got %+v, %v for empty output, expected no items", items, err)
+	}
+
+	_, err = parse("% Invalid input detected at '^' marker.\n")
+	if err == nil {
+		t.Error("expected an error for output without sensors")
+	}
+}
+
+func TestParseCiscoInterfaces(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		names   []string
+		wantErr bool
+	}{
+		{
+			name: "interfaces",
+			output: `GigabitEthernet0/0/0 is up, line protocol is up
+  Hardware is BUILT-IN-EPA-8x1G, address is 00a3.d1f4.2a00 (bia 00a3.d1f4.2a00)
+  Description: uplink core1
+     1234567 packets input, 987654321 bytes, 0 no buffer
+GigabitEthernet0/0/1 is administratively down, line protocol is down
+  Hardware is BUILT-IN-EPA-8x1G, address is 00a3.d1f4.2a01 (bia 00a3.d1f4.2a01)`,
+			names: []string{"GigabitEthernet0/0/0", "GigabitEthernet0/0/1"},
+		},
+		{name: "empty", output: "\n"},
+		{name: "unknown output", output: "                   ^\n% Invalid input detected at '^' marker.", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			items, err := parseCiscoInterfaces(test.output)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", items)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(items) != len(test.names) {
+				t.Fatalf("got %+v, expected interfaces %v", items, test.names)
+			}
+			for i, name := range test.names {
+				if items[i].Name != name {
+					t.Errorf("got interface %q, expected %q", items[i].Name, name)
+				}
+			}
+		})
+	}
+}
+
+func TestParseCiscoVlans(t *testing.T) {
+	items, err := parseCiscoVlans(`Virtual LAN ID:  10 (IEEE 802.1Q Encapsulation)
+
+   VLAN trunk interfaces for VLAN ID 10:
+
+GigabitEthernet0/0/0.10 (10)
+
+    Total 1200 packets, 345678 bytes input
+    Total 1100 packets, 234567 bytes output`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Name != "GigabitEthernet0/0/0.10" || items[0].InputBytes != 345678 || items[0].OutputBytes != 234567 {
+		t.Errorf("unexpected items %+v", items)
+	}
+
+	items, err = parseCiscoVlans("")
+	if err != nil || len(items) != 0 {
+		t.Errorf("got %+v, %v for empty output, expected no items", items, err)
+	}
+
+	_, err = parseCiscoVlans("                   ^\n% Invalid input detected at '^' marker.")
+	if err == nil {
+		t.Error("expected an error for output without vlans")
+	}
+}
@@ -0,0 +1,170 @@
+// Package driver describes the platforms supported by the exporter: how to talk to them and which commands and parsers to use per collector.
+package driver
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// OS types
+const (
+	IOSXE    string = "IOSXE"
+	NXOS     string = "NXOS"
+	IOS      string = "IOS"
+	EOS      string = "EOS"
+	JUNOS    string = "JUNOS"
+	VRP      string = "VRP"
+	COMWARE  string = "COMWARE"
+	ROUTEROS string = "ROUTEROS"
+	LINUX    string = "LINUX"
+)
+
+// Driver declares everything OS specific of a platform. Capabilities a platform does not support are nil.
+type Driver struct {
+	// OS is the OS type
+	OS string
+	// Prompt matches the prompt at the end of the output
+	Prompt *regexp.Regexp
+	// PagerDisable is the command turning off the pager for the session
+	PagerDisable string
+	// Privilege is the command escalating to privileged mode
+	Privilege string
+	// PrivilegePrompt matches the password prompt of the privilege escalation command
+	PrivilegePrompt *regexp.Regexp
+	// Allowlist are the read-only commands allowed by default, patterns have to match the whole command
+	Allowlist []string
+	// Info extracts version, model and hostname from the output of the identification probe
+	Info InfoPatterns
+
+	BGP         *BGPCommand
+	Environment *EnvironmentCommand
+	Facts       *FactsCommands
+	Interfaces  *InterfacesCommand
+	Optics      *OpticsCommands
+	Ping        *PingCommand
+}
+
+// InfoPatterns extract device information, the first submatch is the value
+type InfoPatterns struct {
+	Version  *regexp.Regexp
+	Model    *regexp.Regexp
+	Hostname *regexp.Regexp
+}
+
+// BGPCommand lists the BGP sessions. Fallback is used if the output can not be parsed, e.g. a regex parser for a structured command.
+type BGPCommand struct {
+	Command  string
+	Parse    func(output string) ([]BgpSession, error)
+	Fallback *BGPCommand
+}
+
+// EnvironmentCommand lists temperature sensors and power supplies. Fallback is used if the output can not be parsed.
+// More are further commands, e.g. per kind of sensor, sent in the same exchange; their items are appended.
+type EnvironmentCommand struct {
+	Command  string
+	Parse    func(output string) ([]EnvironmentItem, error)
+	More     []*EnvironmentCommand
+	Fallback *EnvironmentCommand
+}
+
+// FactsCommands collect version, memory and CPU usage
+type FactsCommands struct {
+	Version *VersionCommand
+	Memory  *MemoryCommand
+	CPU     *CPUCommand
+}
+
+// VersionCommand shows the version of the running OS
+type VersionCommand struct {
+	Command string
+	Parse   func(output string) (VersionFact, error)
+}
+
+// MemoryCommand shows the memory usage
+type MemoryCommand struct {
+	Command string
+	Parse   func(output string) ([]MemoryFact, error)
+}
+
+// CPUCommand shows the CPU utilization
+type CPUCommand struct {
+	Command string
+	Parse   func(output string) (CPUFact, error)
+}
+
+// InterfacesCommand lists the interfaces with their counters. Vlans optionally adds the traffic of subinterfaces.
+// Fallback is used if the output can not be parsed.
+type InterfacesCommand struct {
+	Command  string
+	Parse    func(output string) ([]Interface, error)
+	Vlans    *InterfacesCommand
+	Fallback *InterfacesCommand
+}
+
+// OpticsCommands list the interfaces with transceivers and read the signals of each transceiver.
+// If Structured is set, it reads all transceivers at once and the per interface commands are the fallback.
+type OpticsCommands struct {
+	Structured       *OpticsTableCommand
+	Command          string
+	ParseInterfaces  func(output string) ([]string, error)
+	Transceiver      func(iface string) (cmd string, ok bool)
+	ParseTransceiver func(output string) (Optics, error)
+}
+
+// OpticsTableCommand reads the signals of all transceivers, keyed by interface name
+type OpticsTableCommand struct {
+	Command string
+	Parse   func(output string) (map[string]Optics, error)
+}
+
+// PingCommand pings a destination
+type PingCommand struct {
+	Command func(dest string) string
+	Parse   func(output string) (Icmp, error)
+}
+
+var drivers = make(map[string]*Driver)
+
+func register(d *Driver) {
+	drivers[d.OS] = d
+}
+
+// ForOS returns the driver of os, with the configured templates applied, or Generic if os is unknown
+func ForOS(os string) *Driver {
+	os = strings.ToUpper(os)
+
+	templatesMu.RLock()
+	d, found := templated[os]
+	templatesMu.RUnlock()
+	if found {
+		return d
+	}
+
+	d, found = drivers[os]
+	if found {
+		return d
+	}
+
+	return Generic
+}
+
+// Known reports whether there is a driver for os
+func Known(os string) bool {
+	_, found := drivers[strings.ToUpper(os)]
+
+	return found
+}
+
+// All returns all drivers ordered by OS type
+func All() []*Driver {
+	result := make([]*Driver, 0, len(drivers))
+	for _, d := range drivers {
+		result = append(result, d)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].OS < result[j].OS
+	})
+
+	return result
+}
@@ -0,0 +1,177 @@
+package driver
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// parseEOSBGP decodes 'show ip bgp summary vrf all | json'
+func parseEOSBGP(output string) ([]BgpSession, error) {
+	m, err := decodeJSON(output)
+	if err != nil {
+		return nil, err
+	}
+
+	vrfs := jsonObject(m, "vrfs")
+	if vrfs == nil {
+		return nil, errors.New("vrfs not found")
+	}
+
+	items := []BgpSession{}
+	for _, name := range sortedKeys(vrfs) {
+		peers := jsonObject(vrfs, name, "peers")
+		for _, ip := range sortedKeys(peers) {
+			p, ok := peers[ip].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			up := jsonString(p, "peerState") == "Established"
+			pref := 0.0
+			if up {
+				pref = jsonFloat(p, "prefixReceived")
+			}
+			items = append(items, BgpSession{
+				IP:               ip,
+				Asn:              jsonString(p, "asn"),
+				InputMessages:    jsonFloat(p, "msgReceived"),
+				OutputMessages:   jsonFloat(p, "msgSent"),
+				Up:               up,
+				ReceivedPrefixes: pref,
+			})
+		}
+	}
+	return items, nil
+}
+
+// parseEOSInterfaces decodes 'show interfaces | json'
+func parseEOSInterfaces(output string) ([]Interface, error) {
+	m, err := decodeJSON(output)
+	if err != nil {
+		return nil, err
+	}
+
+	interfaces := jsonObject(m, "interfaces")
+	if interfaces == nil {
+		return nil, errors.New("interfaces not found")
+	}
+
+	items := []Interface{}
+	for _, name := range sortedKeys(interfaces) {
+		i, ok := interfaces[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		counters := jsonObject(i, "interfaceCounters")
+
+		item := Interface{
+			Name:           name,
+			MacAddress:     jsonString(i, "physicalAddress"),
+			Description:    jsonString(i, "description"),
+			AdminStatus:    "up",
+			OperStatus:     "down",
+			InputErrors:    jsonFloat(counters, "totalInErrors"),
+			OutputErrors:   jsonFloat(counters, "totalOutErrors"),
+			InputDrops:     jsonFloat(counters, "inDiscards"),
+			OutputDrops:    jsonFloat(counters, "outDiscards"),
+			InputBytes:     jsonFloat(counters, "inOctets"),
+			OutputBytes:    jsonFloat(counters, "outOctets"),
+			InputBroadcast: jsonFloat(counters, "inBroadcastPkts"),
+			InputMulticast: jsonFloat(counters, "inMulticastPkts"),
+		}
+		if jsonString(i, "interfaceStatus") == "disabled" {
+			item.AdminStatus = "down"
+		}
+		if jsonString(i, "lineProtocolStatus") == "up" {
+			item.OperStatus = "up"
+		}
+		if bw := jsonFloat(i, "bandwidth"); bw > 0 {
+			item.Speed = strconv.FormatFloat(bw/1e6, 'f', -1, 64) + " Mb/s"
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// parseEOSOptics decodes 'show interfaces transceiver | json'
+func parseEOSOptics(output string) (map[string]Optics, error) {
+	m, err := decodeJSON(output)
+	if err != nil {
+		return nil, err
+	}
+
+	interfaces := jsonObject(m, "interfaces")
+	if interfaces == nil {
+		return nil, errors.New("interfaces not found")
+	}
+
+	optics := make(map[string]Optics)
+	for name, v := range interfaces {
+		i, ok := v.(map[string]interface{})
+		if !ok || i["txPower"] == nil {
+			continue
+		}
+		optics[name] = Optics{
+			TxPower: jsonFloat(i, "txPower"),
+			RxPower: jsonFloat(i, "rxPower"),
+		}
+	}
+	return optics, nil
+}
+
+// parseEOSTemperature decodes 'show environment temperature | json', the sensors of cards and power supplies included
+func parseEOSTemperature(output string) ([]EnvironmentItem, error) {
+	m, err := decodeJSON(output)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, found := m["tempSensors"]; !found {
+		return nil, errors.New("tempSensors not found")
+	}
+
+	sensors := jsonArray(m, "tempSensors")
+	for _, slots := range []string{"cardSlots", "powerSupplySlots"} {
+		for _, slot := range jsonArray(m, slots) {
+			sensors = append(sensors, jsonArray(slot, "tempSensors")...)
+		}
+	}
+
+	items := []EnvironmentItem{}
+	for _, s := range sensors {
+		items = append(items, EnvironmentItem{
+			Name:        jsonString(s, "name"),
+			IsTemp:      true,
+			Temperature: jsonFloat(s, "currentTemperature"),
+		})
+	}
+	return items, nil
+}
+
+// parseEOSPower decodes 'show environment power | json'
+func parseEOSPower(output string) ([]EnvironmentItem, error) {
+	m, err := decodeJSON(output)
+	if err != nil {
+		return nil, err
+	}
+
+	supplies := jsonObject(m, "powerSupplies")
+	if supplies == nil {
+		return nil, errors.New("powerSupplies not found")
+	}
+
+	items := []EnvironmentItem{}
+	for _, slot := range sortedKeys(supplies) {
+		p, ok := supplies[slot].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		status := jsonString(p, "state")
+		items = append(items, EnvironmentItem{
+			Name:   strings.TrimSpace(slot + " " + jsonString(p, "modelName")),
+			Status: status,
+			OK:     status == "ok",
+		})
+	}
+	return items, nil
+}
@@ -0,0 +1,13 @@
+package driver
+
+import (
+	"regexp"
+)
+
+// Generic is used until the OS of a device is known. Its prompt matches the prompts of all supported OSes:
+// Cisco/Arista "r1#" "r1>", Linux "root@host:~# " "[user@host ~]$ ", VRP/Comware "<HUAWEI>" "[~HUAWEI]",
+// Junos "user@router> ", RouterOS "[admin@MikroTik] > ". It pings like Linux.
+var Generic = &Driver{
+	Prompt: regexp.MustCompile(`(?m)^(?:[\w.@:/()~-]+[#>]|.*[#$] |<[\w.@:()-]+>|\[[~*]*[\w.@:()/-]+\]|\[[^\]\n]+\] >)[ \t]*\z`),
+	Ping:   linuxPing,
+}
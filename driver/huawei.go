@@ -0,0 +1,90 @@
+package driver
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/shenjler/ssh_ping_exporter/util"
+)
+
+var (
+	vrpPrompt     = regexp.MustCompile(`(?m)^(?:<[\w.@:()-]+>|\[~?\*?[\w.@:()/-]+\])[ \t]*\z`)
+	vrpSysname    = regexp.MustCompile(`(?m)^Sysname\s*: (\S+)`)
+	vrpPeerRegexp = regexp.MustCompile(`^\s*(\S+)\s+4\s+(\d+)\s+(\d+)\s+(\d+)\s+\d+\s+\S+\s+(\S+)\s+(\d+)\s*$`)
+	vrpPeerHeader = regexp.MustCompile(`(?m)^\s*Peer\s+V\s+AS\s`)
+	vrpBGPSummary = regexp.MustCompile(`(?m)^\s*BGP local router ID :`)
+	huaweiPing    = &PingCommand{
+		Command: func(dest string) string { return "ping -c 3 " + dest },
+		Parse:   parseUnixPing,
+	}
+)
+
+func init() {
+	register(&Driver{
+		OS:           VRP,
+		Prompt:       vrpPrompt,
+		PagerDisable: "screen-length 0 temporary",
+		Allowlist: []string{
+			`display ` + argsPattern + filterPattern,
+			`screen-length 0 temporary`,
+			`ping (?:-c \d+ )?(?:-vpn-instance [\w.-]+ )?` + hostPattern,
+		},
+		Info: InfoPatterns{
+			Version:  regexp.MustCompile(`(?m)^VRP \(R\) software, Version (\S+ \([^)]*\))`),
+			Model:    regexp.MustCompile(`(?m)^(?:HUAWEI|Quidway) (\S+) .*uptime is`),
+			Hostname: vrpSysname,
+		},
+		BGP:  &BGPCommand{Command: "display bgp peer", Parse: parseVRPBGP},
+		Ping: huaweiPing,
+	})
+
+	register(&Driver{
+		OS:           COMWARE,
+		Prompt:       vrpPrompt,
+		PagerDisable: "screen-length disable",
+		Allowlist: []string{
+			`display ` + argsPattern + filterPattern,
+			`screen-length disable`,
+			`ping (?:-c \d+ )?` + hostPattern,
+		},
+		Info: InfoPatterns{
+			Version:  regexp.MustCompile(`(?m)Comware Software, Version (\S+(?:, Release \S+)?)`),
+			Model:    regexp.MustCompile(`(?m)^H3C (\S+) uptime is`),
+			Hostname: vrpSysname,
+		},
+		Ping: huaweiPing,
+	})
+}
+
+// parseVRPBGP parses 'display bgp peer' and tries to find bgp sessions with related data.
+// Output without peer table is only valid if it is empty or has the summary header, a table without a peer is never valid.
+func parseVRPBGP(output string) ([]BgpSession, error) {
+	items := []BgpSession{}
+	lines := strings.Split(output, "\n")
+	for _, line := range lines {
+		matches := vrpPeerRegexp.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		up := matches[5] == "Established"
+		pref := util.Str2float64(matches[6])
+		if !up {
+			pref = 0
+		}
+		items = append(items, BgpSession{
+			IP:               matches[1],
+			Asn:              matches[2],
+			InputMessages:    util.Str2float64(matches[3]),
+			OutputMessages:   util.Str2float64(matches[4]),
+			Up:               up,
+			ReceivedPrefixes: pref,
+		})
+	}
+	if len(items) == 0 {
+		err := checkBGPWithoutPeers(output, vrpPeerHeader, vrpBGPSummary)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,55 @@
+package driver
+
+import "testing"
+
+const vrpBGPSummaryHeader = `
+ BGP local router ID : 10.255.0.2
+ Local AS number : 65000
+ Total number of peers : 2                 Peers in established state : 1
+`
+
+func TestParseVRPBGP(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		sessions int
+		wantErr  bool
+	}{
+		{
+			name: "peers",
+			output: vrpBGPSummaryHeader + `
+  Peer            V          AS  MsgRcvd  MsgSent  OutQ  Up/Down       State  PrefRcv
+  10.0.0.1        4       65001     1234     1235     0 0026h02m Established       12
+  10.0.0.9        4       65002        0        0     0 0000h00m Idle               0`,
+			sessions: 2,
+		},
+		{name: "empty", output: ""},
+		{name: "no peers", output: vrpBGPSummaryHeader},
+		{
+			name: "unknown peer format",
+			output: vrpBGPSummaryHeader + `
+  Peer            V          AS  MsgRcvd  MsgSent  OutQ  Up/Down       State  PrefRcv
+  10.0.0.1        4       65001     1234     1235     0 0026h02m Established`,
+			wantErr: true,
+		},
+		{name: "unknown output", output: "Error: Unrecognized command found at '^' position.\n", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			items, err := parseVRPBGP(test.output)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", items)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(items) != test.sessions {
+				t.Errorf("got %d sessions, expected %d", len(items), test.sessions)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package driver
+
+import (
+	"encoding/xml"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/shenjler/ssh_ping_exporter/util"
+)
+
+func init() {
+	register(&Driver{
+		OS:           JUNOS,
+		Prompt:       regexp.MustCompile(`(?m)^[\w.-]+@[\w.-]+[>#%][ \t]*\z`),
+		PagerDisable: "set cli screen-length 0",
+		Allowlist: []string{
+			`show ` + argsPattern + filterPattern,
+			`set cli screen-length 0`,
+			`ping count \d+ ` + hostPattern,
+		},
+		Info: InfoPatterns{
+			Version:  regexp.MustCompile(`(?m)^(?:Junos: |JUNOS .*\[)([^\]\s]+)`),
+			Model:    regexp.MustCompile(`(?m)^Model: (\S+)`),
+			Hostname: regexp.MustCompile(`(?m)^Hostname: (\S+)`),
+		},
+		BGP:         &BGPCommand{Command: "show bgp neighbor | display xml", Parse: parseJunosBGP},
+		Environment: &EnvironmentCommand{Command: "show chassis environment | display xml", Parse: parseJunosEnvironment},
+		Interfaces:  &InterfacesCommand{Command: "show interfaces extensive | display xml", Parse: parseJunosInterfaces},
+		Optics: &OpticsCommands{
+			Structured: &OpticsTableCommand{Command: "show interfaces diagnostics optics | display xml", Parse: parseJunosOptics},
+		},
+	})
+}
+
+// decodeXML decodes the first element of the output of a "| display xml" command into v
+func decodeXML(output string, v interface{}) error {
+	i := strings.Index(output, "<")
+	if i < 0 {
+		return errors.New("no XML in output")
+	}
+
+	return xml.Unmarshal([]byte(output[i:]), v)
+}
+
+// junosFloat parses a number of the XML output, Junos pads values with newlines
+func junosFloat(s string) float64 {
+	return util.Str2float64(strings.TrimSpace(s))
+}
+
+type junosBGPReply struct {
+	Information *struct {
+		Peers []struct {
+			Address        string `xml:"peer-address"`
+			AS             string `xml:"peer-as"`
+			State          string `xml:"peer-state"`
+			InputMessages  string `xml:"input-messages"`
+			OutputMessages string `xml:"output-messages"`
+			RIBs           []struct {
+				ReceivedPrefixes string `xml:"received-prefix-count"`
+			} `xml:"bgp-rib"`
+		} `xml:"bgp-peer"`
+	} `xml:"bgp-information"`
+}
+
+// parseJunosBGP decodes 'show bgp neighbor | display xml'
+func parseJunosBGP(output string) ([]BgpSession, error) {
+	var reply junosBGPReply
+	err := decodeXML(output, &reply)
+	if err != nil {
+		return nil, err
+	}
+
+	if reply.Information == nil {
+		return nil, errors.New("bgp-information not found")
+	}
+
+	items := []BgpSession{}
+	for _, p := range reply.Information.Peers {
+		up := strings.TrimSpace(p.State) == "Established"
+		pref := 0.0
+		if up {
+			for _, rib := range p.RIBs {
+				pref += junosFloat(rib.ReceivedPrefixes)
+			}
+		}
+		items = append(items, BgpSession{
+			IP:               strings.SplitN(strings.TrimSpace(p.Address), "+", 2)[0],
+			Asn:              strings.TrimSpace(p.AS),
+			InputMessages:    junosFloat(p.InputMessages),
+			OutputMessages:   junosFloat(p.OutputMessages),
+			Up:               up,
+			ReceivedPrefixes: pref,
+		})
+	}
+	return items, nil
+}
+
+type junosInterfacesReply struct {
+	Information *struct {
+		Interfaces []struct {
+			Name        string `xml:"name"`
+			AdminStatus string `xml:"admin-status"`
+			OperStatus  string `xml:"oper-status"`
+			Description string `xml:"description"`
+			MacAddress  string `xml:"current-physical-address"`
+			Speed       string `xml:"speed"`
+			InputBytes  string `xml:"traffic-statistics>input-bytes"`
+			OutputBytes string `xml:"traffic-statistics>output-bytes"`
+			InputErrors string `xml:"input-error-list>input-errors"`
+			InputDrops  string `xml:"input-error-list>input-drops"`
+			OutErrors   string `xml:"output-error-list>output-errors"`
+			OutDrops    string `xml:"output-error-list>output-drops"`
+			Broadcasts  string `xml:"ethernet-mac-statistics>input-broadcasts"`
+			Multicasts  string `xml:"ethernet-mac-statistics>input-multicasts"`
+		} `xml:"physical-interface"`
+	} `xml:"interface-information"`
+}
+
+// parseJunosInterfaces decodes 'show interfaces extensive | display xml'
+func parseJunosInterfaces(output string) ([]Interface, error) {
+	var reply junosInterfacesReply
+	err := decodeXML(output, &reply)
+	if err != nil {
+		return nil, err
+	}
+
+	if reply.Information == nil {
+		return nil, errors.New("interface-information not found")
+	}
+
+	items := []Interface{}
+	for _, i := range reply.Information.Interfaces {
+		items = append(items, Interface{
+			Name:           strings.TrimSpace(i.Name),
+			MacAddress:     strings.TrimSpace(i.MacAddress),
+			Description:    strings.TrimSpace(i.Description),
+			AdminStatus:    strings.TrimSpace(i.AdminStatus),
+			OperStatus:     strings.TrimSpace(i.OperStatus),
+			InputErrors:    junosFloat(i.InputErrors),
+			OutputErrors:   junosFloat(i.OutErrors),
+			InputDrops:     junosFloat(i.InputDrops),
+			OutputDrops:    junosFloat(i.OutDrops),
+			InputBytes:     junosFloat(i.InputBytes),
+			OutputBytes:    junosFloat(i.OutputBytes),
+			InputBroadcast: junosFloat(i.Broadcasts),
+			InputMulticast: junosFloat(i.Multicasts),
+			Speed:          strings.TrimSpace(i.Speed),
+		})
+	}
+	return items, nil
+}
+
+type junosOpticsReply struct {
+	Information *struct {
+		Interfaces []struct {
+			Name         string `xml:"name"`
+			TxPower      string `xml:"optics-diagnostics>laser-output-power-dbm"`
+			RxPower      string `xml:"optics-diagnostics>rx-signal-avg-optical-power-dbm"`
+			RxLaserPower string `xml:"optics-diagnostics>laser-rx-optical-power-dbm"`
+		} `xml:"physical-interface"`
+	} `xml:"interface-information"`
+}
+
+// parseJunosOptics decodes 'show interfaces diagnostics optics | display xml'
+func parseJunosOptics(output string) (map[string]Optics, error) {
+	var reply junosOpticsReply
+	err := decodeXML(output, &reply)
+	if err != nil {
+		return nil, err
+	}
+
+	if reply.Information == nil {
+		return nil, errors.New("interface-information not found")
+	}
+
+	optics := make(map[string]Optics)
+	for _, i := range reply.Information.Interfaces {
+		rx := i.RxPower
+		if strings.TrimSpace(rx) == "" {
+			rx = i.RxLaserPower
+		}
+		if strings.TrimSpace(i.TxPower) == "" {
+			continue
+		}
+		optics[strings.TrimSpace(i.Name)] = Optics{
+			TxPower: junosFloat(i.TxPower),
+			RxPower: junosFloat(rx),
+		}
+	}
+	return optics, nil
+}
+
+type junosEnvironmentReply struct {
+	Information *struct {
+		Items []struct {
+			Name        string `xml:"name"`
+			Class       string `xml:"class"`
+			Status      string `xml:"status"`
+			Temperature struct {
+				Celsius string `xml:"celsius,attr"`
+			} `xml:"temperature"`
+		} `xml:"environment-item"`
+	} `xml:"environment-information"`
+}
+
+// parseJunosEnvironment decodes 'show chassis environment | display xml', temperatures and power supplies are reported
+func parseJunosEnvironment(output string) ([]EnvironmentItem, error) {
+	var reply junosEnvironmentReply
+	err := decodeXML(output, &reply)
+	if err != nil {
+		return nil, err
+	}
+
+	if reply.Information == nil {
+		return nil, errors.New("environment-information not found")
+	}
+
+	items := []EnvironmentItem{}
+	for _, e := range reply.Information.Items {
+		name := strings.TrimSpace(e.Name)
+		status := strings.TrimSpace(e.Status)
+		switch strings.TrimSpace(e.Class) {
+		case "Temp":
+			if e.Temperature.Celsius == "" {
+				continue
+			}
+			items = append(items, EnvironmentItem{Name: name, IsTemp: true, Temperature: junosFloat(e.Temperature.Celsius)})
+		case "Power":
+			items = append(items, EnvironmentItem{Name: name, Status: status, OK: status == "OK"})
+		}
+	}
+	return items, nil
+}
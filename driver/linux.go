@@ -0,0 +1,27 @@
+package driver
+
+import (
+	"regexp"
+)
+
+var linuxPing = &PingCommand{
+	Command: func(dest string) string { return "ping -c 3 " + dest },
+	Parse:   parseUnixPing,
+}
+
+func init() {
+	register(&Driver{
+		OS:     LINUX,
+		Prompt: regexp.MustCompile(`(?m)^.*[#$] \z`),
+		Allowlist: []string{
+			`uname -a`,
+			`hostname`,
+			`ping -c \d+ (?:-W \d+ )?` + hostPattern,
+		},
+		Info: InfoPatterns{
+			Version:  regexp.MustCompile(`^Linux \S+ (\S+)`),
+			Hostname: regexp.MustCompile(`^Linux (\S+)`),
+		},
+		Ping: linuxPing,
+	})
+}
@@ -0,0 +1,132 @@
+package driver
+
+import (
+	"errors"
+	"strings"
+)
+
+// parseNXOSBGP decodes 'show bgp all summary | json'
+func parseNXOSBGP(output string) ([]BgpSession, error) {
+	m, err := decodeJSON(output)
+	if err != nil {
+		return nil, err
+	}
+
+	vrfs := nxosRows(m, "vrf")
+	if vrfs == nil {
+		return nil, errors.New("TABLE_vrf not found")
+	}
+
+	items := []BgpSession{}
+	for _, vrf := range vrfs {
+		for _, af := range nxosRows(vrf, "af") {
+			for _, saf := range nxosRows(af, "saf") {
+				for _, n := range nxosRows(saf, "neighbor") {
+					up := jsonString(n, "state") == "Established"
+					pref := 0.0
+					if up {
+						pref = jsonFloat(n, "prefixreceived")
+					}
+					items = append(items, BgpSession{
+						IP:               jsonString(n, "neighborid"),
+						Asn:              jsonString(n, "neighboras"),
+						InputMessages:    jsonFloat(n, "msgrecvd"),
+						OutputMessages:   jsonFloat(n, "msgsent"),
+						Up:               up,
+						ReceivedPrefixes: pref,
+					})
+				}
+			}
+		}
+	}
+	return items, nil
+}
+
+// parseNXOSInterfaces decodes 'show interface | json'
+func parseNXOSInterfaces(output string) ([]Interface, error) {
+	m, err := decodeJSON(output)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := nxosRows(m, "interface")
+	if rows == nil {
+		return nil, errors.New("TABLE_interface not found")
+	}
+
+	items := []Interface{}
+	for _, r := range rows {
+		items = append(items, Interface{
+			Name:           jsonString(r, "interface"),
+			MacAddress:     jsonString(r, "eth_hw_addr"),
+			Description:    jsonString(r, "desc"),
+			AdminStatus:    jsonString(r, "admin_state"),
+			OperStatus:     jsonString(r, "state"),
+			InputErrors:    jsonFloat(r, "eth_inerr"),
+			OutputErrors:   jsonFloat(r, "eth_outerr"),
+			InputDrops:     jsonFloat(r, "eth_indiscard"),
+			OutputDrops:    jsonFloat(r, "eth_outdiscard"),
+			InputBytes:     jsonFloat(r, "eth_inbytes"),
+			OutputBytes:    jsonFloat(r, "eth_outbytes"),
+			InputBroadcast: jsonFloat(r, "eth_inbcast"),
+			InputMulticast: jsonFloat(r, "eth_inmcast"),
+			Speed:          jsonString(r, "eth_speed"),
+		})
+	}
+	return items, nil
+}
+
+// parseNXOSOptics decodes 'show interface transceiver details | json', the first lane is used
+func parseNXOSOptics(output string) (map[string]Optics, error) {
+	m, err := decodeJSON(output)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := nxosRows(m, "interface")
+	if rows == nil {
+		return nil, errors.New("TABLE_interface not found")
+	}
+
+	optics := make(map[string]Optics)
+	for _, r := range rows {
+		lanes := nxosRows(r, "lane")
+		if len(lanes) == 0 {
+			continue
+		}
+		optics[jsonString(r, "interface")] = Optics{
+			TxPower: jsonFloat(lanes[0], "tx_pwr"),
+			RxPower: jsonFloat(lanes[0], "rx_pwr"),
+		}
+	}
+	return optics, nil
+}
+
+// parseNXOSEnvironment decodes 'show environment | json'
+func parseNXOSEnvironment(output string) ([]EnvironmentItem, error) {
+	m, err := decodeJSON(output)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []EnvironmentItem{}
+	for _, r := range nxosRows(m, "tempinfo") {
+		items = append(items, EnvironmentItem{
+			Name:        strings.TrimSpace(jsonString(r, "tempmod") + " " + jsonString(r, "sensor")),
+			IsTemp:      true,
+			Temperature: jsonFloat(r, "curtemp"),
+		})
+	}
+	for _, r := range nxosRows(jsonObject(m, "powersup"), "psinfo") {
+		status := jsonString(r, "ps_status")
+		items = append(items, EnvironmentItem{
+			Name:   strings.TrimSpace(jsonString(r, "psnum") + " " + jsonString(r, "input_type")),
+			Status: status,
+			OK:     strings.EqualFold(status, "ok"),
+		})
+	}
+	if len(items) == 0 {
+		return nil, errors.New("TABLE_tempinfo and TABLE_psinfo not found")
+	}
+	return items, nil
+}
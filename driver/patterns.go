@@ -0,0 +1,10 @@
+package driver
+
+const (
+	// hostPattern matches host names, IPv4 and IPv6 addresses but no shell metacharacters
+	hostPattern = `[A-Za-z0-9][A-Za-z0-9.:-]*`
+	// argsPattern matches command arguments without pipes and shell metacharacters
+	argsPattern = "[^|;&$`<>]+"
+	// filterPattern matches output filters which do not write anywhere (no redirect, tee, append)
+	filterPattern = `(?:\s*\|\s*(?:(?:include|exclude|begin|section|count|no-more|json|i|e|b|s)(?: ` + argsPattern + `)?|display (?:json|xml)))*`
+)
@@ -0,0 +1,51 @@
+package driver
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/shenjler/ssh_ping_exporter/util"
+)
+
+var (
+	pingTargetRegexp     = regexp.MustCompile(`^\s*--- (.*) ping statistics ---.*$`)                                                                                     // target
+	pingPacketLossRegexp = regexp.MustCompile(`^\s*(?:(?:\d+) packets transmitted, (?:\d+) received, (?:\+\d+ errors, )?)?((?:[1-9][\d]*|0)(?:\.\d+)?)% packet loss.*$`) // packet loss rate
+	pingRttRegexp        = regexp.MustCompile(`^\s*(?:rtt|round-trip)? min/avg/max(?:/mdev)? = ((?:[1-9][\d]*|0)(?:\.[\d]+)?)/((?:[1-9][\d]*|0)(?:\.[\d]+)?)/((?:[1-9][\d]*|0)(?:\.[\d]+)?)(?:/.*)? ms.*$`)
+)
+
+// parseUnixPing parses ping output ending with "--- <target> ping statistics ---" as printed by Linux, VRP and EOS
+func parseUnixPing(output string) (Icmp, error) {
+	current := Icmp{}
+	lines := strings.Split(output, "\n")
+	for _, line := range lines {
+		if matches := pingTargetRegexp.FindStringSubmatch(line); matches != nil {
+			current = Icmp{
+				Target: matches[1],
+			}
+		}
+		if current == (Icmp{}) {
+			continue
+		}
+		if matches := pingPacketLossRegexp.FindStringSubmatch(line); matches != nil {
+			current.PacketLoss = util.Str2float64(matches[1])
+			if current.PacketLoss == 100 {
+				current.PingStatus = "down"
+			} else {
+				current.PingStatus = "up"
+			}
+		}
+		if matches := pingRttRegexp.FindStringSubmatch(line); matches != nil {
+			current.RttMin = util.Str2float64(matches[1])
+			current.RttAvg = util.Str2float64(matches[2])
+			current.RttMax = util.Str2float64(matches[3])
+		}
+	}
+	if current.Target == "" {
+		return current, errors.New("Ping statistics not found")
+	}
+	if current.PingStatus == "" {
+		return current, errors.New("Packet loss not found")
+	}
+	return current, nil
+}
@@ -0,0 +1,70 @@
+package driver
+
+import "testing"
+
+func TestParseUnixPing(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    Icmp
+		wantErr bool
+	}{
+		{
+			name: "linux",
+			output: `PING 192.0.2.1 (192.0.2.1) 56(84) bytes of data.
+64 bytes from 192.0.2.1: icmp_seq=1 ttl=64 time=31.3 ms
+
+--- 192.0.2.1 ping statistics ---
+3 packets transmitted, 3 received, 0% packet loss, time 2002ms
+rtt min/avg/max/mdev = 31.300/32.600/33.900/1.100 ms`,
+			want: Icmp{Target: "192.0.2.1", PacketLoss: 0, PingStatus: "up", RttMin: 31.3, RttAvg: 32.6, RttMax: 33.9},
+		},
+		{
+			name: "linux unreachable",
+			output: `PING 192.0.2.9 (192.0.2.9) 56(84) bytes of data.
+From 192.0.2.254 icmp_seq=1 Destination Host Unreachable
+
+--- 192.0.2.9 ping statistics ---
+3 packets transmitted, 0 received, +3 errors, 100% packet loss, time 2031ms`,
+			want: Icmp{Target: "192.0.2.9", PacketLoss: 100, PingStatus: "down"},
+		},
+		{
+			name: "vrp",
+			output: `  PING 192.0.2.1: 56  data bytes, press CTRL_C to break
+    Reply from 192.0.2.1: bytes=56 Sequence=1 ttl=254 time=2 ms
+
+  --- 192.0.2.1 ping statistics ---
+    3 packet(s) transmitted
+    3 packet(s) received
+    0.00% packet loss
+    round-trip min/avg/max = 1/1/2 ms`,
+			want: Icmp{Target: "192.0.2.1", PacketLoss: 0, PingStatus: "up", RttMin: 1, RttAvg: 1, RttMax: 2},
+		},
+		{name: "unknown host", output: "ping: lab-gw: Name or service not known", wantErr: true},
+		{
+			name: "truncated",
+			output: `PING 192.0.2.1 (192.0.2.1) 56(84) bytes of data.
+
+--- 192.0.2.1 ping statistics ---`,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseUnixPing(test.output)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got %+v, expected %+v", got, test.want)
+			}
+		})
+	}
+}
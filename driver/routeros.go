@@ -0,0 +1,20 @@
+package driver
+
+import (
+	"regexp"
+)
+
+func init() {
+	register(&Driver{
+		OS:     ROUTEROS,
+		Prompt: regexp.MustCompile(`(?m)^\[[^\]\n]+\] >[ \t]*\z`),
+		Allowlist: []string{
+			`/(?:[\w-]+ )+print(?: (?:detail|terse|stats|without-paging))*`,
+			`/ping ` + hostPattern + `(?: count=\d+)?`,
+		},
+		Info: InfoPatterns{
+			Version: regexp.MustCompile(`(?m)^\s*version: (\S+)`),
+			Model:   regexp.MustCompile(`(?m)^\s*board-name: (.+?)\s*$`),
+		},
+	})
+}
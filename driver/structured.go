@@ -0,0 +1,235 @@
+package driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Runner runs a command on the device and returns its output
+type Runner func(cmd string) (string, error)
+
+// BatchRunner runs commands on the device in one exchange and returns the output of each
+type BatchRunner func(cmds []string) ([]string, error)
+
+// ParseError is returned if the output of a command could not be parsed
+type ParseError struct {
+	Command string
+	Err     error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("could not parse output of %q: %s", e.Command, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Run runs the command and parses its output. If the output can not be parsed the fallback is tried.
+func (c *BGPCommand) Run(run Runner) ([]BgpSession, error) {
+	out, err := run(c.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := c.Parse(out)
+	if err != nil {
+		if c.Fallback != nil {
+			return c.Fallback.Run(run)
+		}
+		return nil, &ParseError{Command: c.Command, Err: err}
+	}
+
+	return items, nil
+}
+
+// Run runs the command and the further ones in one batch and parses their output.
+// If the output of the command can not be parsed the fallback is tried.
+func (c *EnvironmentCommand) Run(run BatchRunner) ([]EnvironmentItem, error) {
+	cmds := append([]*EnvironmentCommand{c}, c.More...)
+	lines := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		lines[i] = cmd.Command
+	}
+
+	outputs, err := run(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []EnvironmentItem
+	for i, cmd := range cmds {
+		parsed, err := cmd.Parse(outputs[i])
+		if err != nil {
+			if i == 0 && c.Fallback != nil {
+				return c.Fallback.Run(run)
+			}
+			return nil, &ParseError{Command: cmd.Command, Err: err}
+		}
+		items = append(items, parsed...)
+	}
+
+	return items, nil
+}
+
+// Run runs the command and parses its output. If the output can not be parsed the fallback is tried.
+// The traffic of subinterfaces is added if the command has Vlans.
+func (c *InterfacesCommand) Run(run Runner) ([]Interface, error) {
+	out, err := run(c.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := c.Parse(out)
+	if err != nil {
+		if c.Fallback != nil {
+			return c.Fallback.Run(run)
+		}
+		return nil, &ParseError{Command: c.Command, Err: err}
+	}
+
+	if c.Vlans == nil {
+		return items, nil
+	}
+
+	vlans, err := c.Vlans.Run(run)
+	if err != nil {
+		return nil, err
+	}
+	for _, vlan := range vlans {
+		for i, item := range items {
+			if item.Name == vlan.Name {
+				items[i].InputBytes = vlan.InputBytes
+				items[i].OutputBytes = vlan.OutputBytes
+				break
+			}
+		}
+	}
+
+	return items, nil
+}
+
+// Run runs the command and parses its output
+func (c *OpticsTableCommand) Run(run Runner) (map[string]Optics, error) {
+	out, err := run(c.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	optics, err := c.Parse(out)
+	if err != nil {
+		return nil, &ParseError{Command: c.Command, Err: err}
+	}
+
+	return optics, nil
+}
+
+// decodeJSON decodes the output of a "| json" command, leading noise before the object is skipped
+func decodeJSON(output string) (map[string]interface{}, error) {
+	i := strings.Index(output, "{")
+	if i < 0 {
+		return nil, fmt.Errorf("no JSON object in output")
+	}
+
+	var m map[string]interface{}
+	err := json.Unmarshal([]byte(output[i:]), &m)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// jsonObject returns the object at path in m
+func jsonObject(m map[string]interface{}, path ...string) map[string]interface{} {
+	for _, key := range path {
+		next, ok := m[key].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		m = next
+	}
+
+	return m
+}
+
+// jsonArray returns the objects of the array at key in m
+func jsonArray(m map[string]interface{}, key string) []map[string]interface{} {
+	arr, ok := m[key].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	rows := make([]map[string]interface{}, 0, len(arr))
+	for _, v := range arr {
+		if obj, ok := v.(map[string]interface{}); ok {
+			rows = append(rows, obj)
+		}
+	}
+
+	return rows
+}
+
+// sortedKeys returns the keys of m in order, the output does not depend on the order of the JSON objects
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// nxosRows returns the rows of an NX-OS table: TABLE_<name>.ROW_<name> is an object if the table has one row, an array otherwise
+func nxosRows(m map[string]interface{}, name string) []map[string]interface{} {
+	table, ok := m["TABLE_"+name].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	switch row := table["ROW_"+name].(type) {
+	case map[string]interface{}:
+		return []map[string]interface{}{row}
+	case []interface{}:
+		rows := make([]map[string]interface{}, 0, len(row))
+		for _, r := range row {
+			if obj, ok := r.(map[string]interface{}); ok {
+				rows = append(rows, obj)
+			}
+		}
+		return rows
+	}
+
+	return nil
+}
+
+// jsonString returns the value of key as string, numbers are formatted
+func jsonString(m map[string]interface{}, key string) string {
+	switch v := m[key].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+
+	return ""
+}
+
+// jsonFloat returns the value of key as number, strings are parsed
+func jsonFloat(m map[string]interface{}, key string) float64 {
+	switch v := m[key].(type) {
+	case float64:
+		return v
+	case string:
+		f, _ := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f
+	}
+
+	return 0
+}
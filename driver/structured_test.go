@@ -0,0 +1,268 @@
+package driver
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// the parsers of the table return different types, they are compared as interface{}
+func bgpParser(parse func(string) ([]BgpSession, error)) func(string) (interface{}, error) {
+	return func(output string) (interface{}, error) { return parse(output) }
+}
+
+func environmentItemsParser(parse func(string) ([]EnvironmentItem, error)) func(string) (interface{}, error) {
+	return func(output string) (interface{}, error) { return parse(output) }
+}
+
+func interfacesParser(parse func(string) ([]Interface, error)) func(string) (interface{}, error) {
+	return func(output string) (interface{}, error) { return parse(output) }
+}
+
+func opticsParser(parse func(string) (map[string]Optics, error)) func(string) (interface{}, error) {
+	return func(output string) (interface{}, error) { return parse(output) }
+}
+
+var (
+	establishedSession = BgpSession{IP: "10.0.0.2", Asn: "65001", Up: true, ReceivedPrefixes: 12, InputMessages: 1234, OutputMessages: 1235}
+	idleSession        = BgpSession{IP: "10.0.0.6", Asn: "65002"}
+)
+
+func TestParseStructured(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		output  string
+		parse   func(string) (interface{}, error)
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			name:  "nxos bgp",
+			file:  "nxos_show_bgp_all_summary.json",
+			parse: bgpParser(parseNXOSBGP),
+			want:  []BgpSession{establishedSession, idleSession},
+		},
+		{
+			name:  "nxos interfaces",
+			file:  "nxos_show_interface.json",
+			parse: interfacesParser(parseNXOSInterfaces),
+			want: []Interface{
+				{Name: "mgmt0", MacAddress: "5254.0012.3456", AdminStatus: "up", OperStatus: "up", InputBytes: 123456, OutputBytes: 654321, Speed: "1000 Mb/s"},
+				{
+					Name: "Ethernet1/1", MacAddress: "0022.bdf8.1a01", Description: "uplink core1", AdminStatus: "up", OperStatus: "up",
+					InputErrors: 3, InputDrops: 2, OutputDrops: 1, InputBytes: 987654321, OutputBytes: 876543210, InputBroadcast: 1234, InputMulticast: 5678, Speed: "10 Gb/s",
+				},
+				{Name: "Ethernet1/2", MacAddress: "0022.bdf8.1a02", AdminStatus: "down", OperStatus: "down", Speed: "auto-speed"},
+			},
+		},
+		{
+			name:  "nxos optics",
+			file:  "nxos_show_interface_transceiver_details.json",
+			parse: opticsParser(parseNXOSOptics),
+			want:  map[string]Optics{"Ethernet1/1": {TxPower: -2.35, RxPower: -3.12}},
+		},
+		{
+			name:  "nxos environment",
+			file:  "nxos_show_environment.json",
+			parse: environmentItemsParser(parseNXOSEnvironment),
+			want: []EnvironmentItem{
+				{Name: "1 FRONT", IsTemp: true, Temperature: 31},
+				{Name: "1 CPU", IsTemp: true, Temperature: 42},
+				{Name: "1 AC", Status: "Ok", OK: true},
+				{Name: "2 AC", Status: "Shutdown"},
+			},
+		},
+		{name: "nxos bgp without vrf table", output: `{"TABLE_interface": {}}`, parse: bgpParser(parseNXOSBGP), wantErr: true},
+		{name: "nxos bgp without json", output: "% Invalid command at '^' marker.", parse: bgpParser(parseNXOSBGP), wantErr: true},
+		{
+			name:  "eos bgp",
+			file:  "eos_show_ip_bgp_summary_vrf_all.json",
+			parse: bgpParser(parseEOSBGP),
+			want:  []BgpSession{establishedSession, idleSession},
+		},
+		{
+			name:  "eos interfaces",
+			file:  "eos_show_interfaces.json",
+			parse: interfacesParser(parseEOSInterfaces),
+			want: []Interface{
+				{
+					Name: "Ethernet1", MacAddress: "00:1c:73:aa:bb:01", Description: "uplink core1", AdminStatus: "up", OperStatus: "up",
+					InputErrors: 3, InputDrops: 2, OutputDrops: 1, InputBytes: 987654321, OutputBytes: 876543210, InputBroadcast: 1234, InputMulticast: 5678, Speed: "10000 Mb/s",
+				},
+				{Name: "Ethernet2", MacAddress: "00:1c:73:aa:bb:02", AdminStatus: "down", OperStatus: "down"},
+			},
+		},
+		{
+			name:  "eos optics",
+			file:  "eos_show_interfaces_transceiver.json",
+			parse: opticsParser(parseEOSOptics),
+			want:  map[string]Optics{"Ethernet1": {TxPower: -2.35, RxPower: -3.12}},
+		},
+		{
+			name:  "eos temperature",
+			file:  "eos_show_environment_temperature.json",
+			parse: environmentItemsParser(parseEOSTemperature),
+			want: []EnvironmentItem{
+				{Name: "TempSensor1", IsTemp: true, Temperature: 38},
+				{Name: "TempSensor2", IsTemp: true, Temperature: 27.5},
+				{Name: "TempSensorP1/1", IsTemp: true, Temperature: 33},
+			},
+		},
+		{
+			name:  "eos power",
+			file:  "eos_show_environment_power.json",
+			parse: environmentItemsParser(parseEOSPower),
+			want: []EnvironmentItem{
+				{Name: "1 PWR-500AC-F", Status: "ok", OK: true},
+				{Name: "2 PWR-500AC-F", Status: "powerLoss"},
+			},
+		},
+		{name: "eos power without supplies", output: `{"tempSensors": []}`, parse: environmentItemsParser(parseEOSPower), wantErr: true},
+		{
+			name:  "junos bgp",
+			file:  "junos_show_bgp_neighbor.xml",
+			parse: bgpParser(parseJunosBGP),
+			want: []BgpSession{
+				{IP: "10.0.0.2", Asn: "65001", Up: true, ReceivedPrefixes: 15, InputMessages: 1234, OutputMessages: 1235},
+				idleSession,
+			},
+		},
+		{
+			name:  "junos interfaces",
+			file:  "junos_show_interfaces_extensive.xml",
+			parse: interfacesParser(parseJunosInterfaces),
+			want: []Interface{
+				{
+					Name: "ge-0/0/0", MacAddress: "2c:6b:f5:aa:bb:00", Description: "uplink core1", AdminStatus: "up", OperStatus: "up",
+					InputErrors: 3, InputDrops: 2, OutputDrops: 1, InputBytes: 987654321, OutputBytes: 876543210, InputBroadcast: 1234, InputMulticast: 5678, Speed: "1000mbps",
+				},
+				{Name: "ge-0/0/1", MacAddress: "2c:6b:f5:aa:bb:01", AdminStatus: "down", OperStatus: "down", Speed: "1000mbps"},
+			},
+		},
+		{
+			name:  "junos optics",
+			file:  "junos_show_interfaces_diagnostics_optics.xml",
+			parse: opticsParser(parseJunosOptics),
+			want: map[string]Optics{
+				"ge-0/0/0": {TxPower: -2.35, RxPower: -3.12},
+				"xe-0/1/0": {TxPower: -1.8, RxPower: -2.07},
+			},
+		},
+		{
+			name:  "junos environment",
+			file:  "junos_show_chassis_environment.xml",
+			parse: environmentItemsParser(parseJunosEnvironment),
+			want: []EnvironmentItem{
+				{Name: "PEM 0", Status: "OK", OK: true},
+				{Name: "PEM 1", Status: "Absent"},
+				{Name: "Routing Engine 0", IsTemp: true, Temperature: 38},
+			},
+		},
+		{
+			name:    "junos bgp of other command",
+			output:  `<rpc-reply><interface-information></interface-information></rpc-reply>`,
+			parse:   bgpParser(parseJunosBGP),
+			wantErr: true,
+		},
+		{name: "junos error", output: "error: syntax error, expecting <command>: bgpp", parse: bgpParser(parseJunosBGP), wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output := test.output
+			if test.file != "" {
+				b, err := ioutil.ReadFile(filepath.Join("testdata", test.file))
+				if err != nil {
+					t.Fatal(err)
+				}
+				output = string(b)
+			}
+
+			got, err := test.parse(output)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, expected %+v", got, test.want)
+			}
+		})
+	}
+}
+
+// replies answers commands like a device, unknown commands are rejected like NX-OS does
+func replies(outputs map[string]string) Runner {
+	return func(cmd string) (string, error) {
+		if out, found := outputs[cmd]; found {
+			return out, nil
+		}
+		return "                   ^\n% Invalid command at '^' marker.", nil
+	}
+}
+
+func batchReplies(outputs map[string]string) BatchRunner {
+	run := replies(outputs)
+	return func(cmds []string) ([]string, error) {
+		result := make([]string, len(cmds))
+		for i, cmd := range cmds {
+			result[i], _ = run(cmd)
+		}
+		return result, nil
+	}
+}
+
+func TestStructuredFallback(t *testing.T) {
+	d := drivers[NXOS]
+
+	sessions, err := d.BGP.Run(replies(map[string]string{
+		"show bgp all summary": `BGP router identifier 10.255.0.1, local AS number 65000
+
+Neighbor        V           AS MsgRcvd MsgSent   TblVer  InQ OutQ Up/Down  State/PfxRcd
+10.0.0.2        4        65001    1234    1235       42    0    0 1d02h           12`,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sessions, []BgpSession{establishedSession}) {
+		t.Errorf("got %+v from the fallback, expected %+v", sessions, establishedSession)
+	}
+
+	items, err := d.Environment.Run(batchReplies(map[string]string{
+		"show environment": `Module   Sensor        MajorThresh   MinorThres   CurTemp     Status
+                       (Celsius)     (Celsius)    (Celsius)
+1        FRONT           80              70          31         Ok`,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Name != "1 FRONT" || items[0].Temperature != 31 {
+		t.Errorf("got %+v from the fallback, expected the FRONT sensor", items)
+	}
+
+	interfaces, err := d.Interfaces.Run(replies(map[string]string{
+		"show interface": `Ethernet1/1 is up
+admin state is up, Dedicated Interface
+  Hardware: 100/1000/10000 Ethernet, address: 0022.bdf8.1a01 (bia 0022.bdf8.1a01)`,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(interfaces) != 1 || interfaces[0].Name != "Ethernet1/1" {
+		t.Errorf("got %+v from the fallback, expected Ethernet1/1", interfaces)
+	}
+
+	// the error of the fallback is reported if neither can parse the output
+	_, err = d.BGP.Run(replies(nil))
+	var pe *ParseError
+	if !errors.As(err, &pe) || pe.Command != "show bgp all summary" {
+		t.Errorf("got %v, expected a parse error of the fallback command", err)
+	}
+}
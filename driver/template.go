@@ -0,0 +1,285 @@
+package driver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/shenjler/ssh_ping_exporter/config"
+	"github.com/shenjler/ssh_ping_exporter/textfsm"
+)
+
+// Collectors whose parser can be replaced by a template
+const (
+	TemplateBGP         = "bgp"
+	TemplateEnvironment = "environment"
+	TemplateInterfaces  = "interfaces"
+)
+
+var (
+	templatesMu sync.RWMutex
+	templated   = make(map[string]*Driver)
+)
+
+// Templates are copies of the drivers with parsers replaced by TextFSM templates, they take effect with UseTemplates
+type Templates struct {
+	drivers map[string]*Driver
+}
+
+// LoadTemplates loads the TextFSM templates configured in cfg and replaces the parsers of the built-in collectors by them.
+// Drivers without template keep their built-in parsers.
+func LoadTemplates(cfg *config.Config) (*Templates, error) {
+	result := make(map[string]*Driver)
+	for _, tc := range cfg.Templates {
+		os := strings.ToUpper(tc.OS)
+		base, found := drivers[os]
+		if !found {
+			return nil, fmt.Errorf("template %s: unknown OS %q", tc.File, tc.OS)
+		}
+
+		t, err := textfsm.ParseFile(tc.File)
+		if err != nil {
+			return nil, err
+		}
+
+		d, found := result[os]
+		if !found {
+			copied := *base
+			d = &copied
+			result[os] = d
+		}
+
+		err = useTemplate(d, tc.Collector, tc.Command, t)
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %w", tc.File, err)
+		}
+	}
+
+	return &Templates{drivers: result}, nil
+}
+
+// UseTemplates makes ForOS return the drivers of t instead of the ones of the templates used before
+func UseTemplates(t *Templates) {
+	templatesMu.Lock()
+	templated = t.drivers
+	templatesMu.Unlock()
+}
+
+func useTemplate(d *Driver, collector, command string, t *textfsm.Template) error {
+	switch collector {
+	case TemplateBGP:
+		if command == "" && d.BGP != nil {
+			command = d.BGP.Command
+		}
+		if err := requireValues(t, bgpIP); err != nil {
+			return err
+		}
+		d.BGP = &BGPCommand{Command: command, Parse: bgpTemplateParser(t)}
+	case TemplateEnvironment:
+		cmd := &EnvironmentCommand{Command: command, Parse: environmentTemplateParser(t)}
+		if d.Environment != nil {
+			if command == "" {
+				cmd.Command = d.Environment.Command
+			}
+			// the template replaces the parser of the main command only, further commands keep their parsers
+			cmd.More = d.Environment.More
+		}
+		if err := requireValues(t, environmentName); err != nil {
+			return err
+		}
+		d.Environment = cmd
+		command = cmd.Command
+	case TemplateInterfaces:
+		cmd := &InterfacesCommand{Command: command, Parse: interfacesTemplateParser(t)}
+		if d.Interfaces != nil {
+			if command == "" {
+				cmd.Command = d.Interfaces.Command
+			}
+			cmd.Vlans = d.Interfaces.Vlans
+		}
+		if err := requireValues(t, interfaceName); err != nil {
+			return err
+		}
+		d.Interfaces = cmd
+		command = cmd.Command
+	default:
+		return fmt.Errorf("templates are not supported for collector %q", collector)
+	}
+
+	if command == "" {
+		return fmt.Errorf("no command for collector %s on %s", collector, d.OS)
+	}
+
+	return nil
+}
+
+// Value names understood per field, the names of ntc-templates come first
+var (
+	bgpIP       = []string{"BGP_NEIGH", "NEIGHBOR", "NEIGHBOR_ID", "PEER"}
+	bgpAsn      = []string{"NEIGH_AS", "REMOTE_AS", "ASN"}
+	bgpState    = []string{"STATE_PFXRCD", "STATE", "BGP_STATE"}
+	bgpPrefixes = []string{"PREFIXES_RECEIVED", "PFX_RCD", "PREF_RCV"}
+	bgpInput    = []string{"MSG_RCVD", "MSGS_RCVD"}
+	bgpOutput   = []string{"MSG_SENT", "MSGS_SENT"}
+
+	environmentName        = []string{"SENSOR", "NAME", "PSU", "FAN"}
+	environmentStatus      = []string{"STATUS", "STATE"}
+	environmentTemperature = []string{"TEMPERATURE", "TEMP", "READING"}
+
+	interfaceName           = []string{"INTERFACE", "NAME"}
+	interfaceMac            = []string{"MAC_ADDRESS", "ADDRESS"}
+	interfaceDescription    = []string{"DESCRIPTION"}
+	interfaceAdmin          = []string{"ADMIN_STATUS", "ADMIN_STATE"}
+	interfaceLink           = []string{"LINK_STATUS", "PHY"}
+	interfaceOper           = []string{"OPER_STATUS", "PROTOCOL_STATUS", "PROTOCOL", "LINK_STATUS"}
+	interfaceInputErrors    = []string{"INPUT_ERRORS", "IN_ERRORS"}
+	interfaceOutputErrors   = []string{"OUTPUT_ERRORS", "OUT_ERRORS"}
+	interfaceInputDrops     = []string{"INPUT_DROPS", "IN_DROPS"}
+	interfaceOutputDrops    = []string{"OUTPUT_DROPS", "OUT_DROPS"}
+	interfaceInputBytes     = []string{"INPUT_BYTES", "IN_BYTES"}
+	interfaceOutputBytes    = []string{"OUTPUT_BYTES", "OUT_BYTES"}
+	interfaceInputBroadcast = []string{"INPUT_BROADCAST", "BROADCASTS"}
+	interfaceInputMulticast = []string{"INPUT_MULTICAST", "MULTICASTS"}
+	interfaceSpeed          = []string{"SPEED", "BANDWIDTH"}
+)
+
+// requireValues returns an error unless the template has one of names
+func requireValues(t *textfsm.Template, names []string) error {
+	for _, v := range t.Values {
+		for _, n := range names {
+			if v.Name == n {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("template needs one of the values %s", strings.Join(names, ", "))
+}
+
+// field returns the first non-empty value of names
+func field(r textfsm.Record, names []string) string {
+	for _, n := range names {
+		if v := strings.TrimSpace(r.String(n)); v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// number returns the first value of names parsed as float, 0 if there is none
+func number(r textfsm.Record, names []string) float64 {
+	s := strings.ReplaceAll(field(r, names), ",", "")
+	f, _ := strconv.ParseFloat(strings.TrimSpace(strings.Split(s, " ")[0]), 64)
+
+	return f
+}
+
+func bgpTemplateParser(t *textfsm.Template) func(string) ([]BgpSession, error) {
+	return func(output string) ([]BgpSession, error) {
+		records, err := t.ParseText(output)
+		if err != nil {
+			return nil, err
+		}
+
+		items := make([]BgpSession, 0, len(records))
+		for _, r := range records {
+			s := BgpSession{
+				IP:             field(r, bgpIP),
+				Asn:            field(r, bgpAsn),
+				InputMessages:  number(r, bgpInput),
+				OutputMessages: number(r, bgpOutput),
+			}
+
+			// State/PfxRcd holds the number of prefixes if the session is established
+			state := field(r, bgpState)
+			if prefixes, err := strconv.ParseFloat(state, 64); err == nil {
+				s.Up = true
+				s.ReceivedPrefixes = prefixes
+			} else {
+				s.Up = strings.EqualFold(state, "Established")
+				s.ReceivedPrefixes = number(r, bgpPrefixes)
+			}
+
+			items = append(items, s)
+		}
+
+		return items, nil
+	}
+}
+
+func environmentTemplateParser(t *textfsm.Template) func(string) ([]EnvironmentItem, error) {
+	return func(output string) ([]EnvironmentItem, error) {
+		records, err := t.ParseText(output)
+		if err != nil {
+			return nil, err
+		}
+
+		items := make([]EnvironmentItem, 0, len(records))
+		for _, r := range records {
+			status := field(r, environmentStatus)
+			item := EnvironmentItem{
+				Name:   field(r, environmentName),
+				Status: status,
+			}
+			switch strings.ToLower(status) {
+			case "ok", "normal", "good", "green", "present", "powered-on":
+				item.OK = true
+			}
+			if field(r, environmentTemperature) != "" {
+				item.IsTemp = true
+				item.Temperature = number(r, environmentTemperature)
+			}
+
+			items = append(items, item)
+		}
+
+		return items, nil
+	}
+}
+
+func interfacesTemplateParser(t *textfsm.Template) func(string) ([]Interface, error) {
+	return func(output string) ([]Interface, error) {
+		records, err := t.ParseText(output)
+		if err != nil {
+			return nil, err
+		}
+
+		items := make([]Interface, 0, len(records))
+		for _, r := range records {
+			item := Interface{
+				Name:           field(r, interfaceName),
+				MacAddress:     field(r, interfaceMac),
+				Description:    field(r, interfaceDescription),
+				AdminStatus:    field(r, interfaceAdmin),
+				OperStatus:     "down",
+				InputErrors:    number(r, interfaceInputErrors),
+				OutputErrors:   number(r, interfaceOutputErrors),
+				InputDrops:     number(r, interfaceInputDrops),
+				OutputDrops:    number(r, interfaceOutputDrops),
+				InputBytes:     number(r, interfaceInputBytes),
+				OutputBytes:    number(r, interfaceOutputBytes),
+				InputBroadcast: number(r, interfaceInputBroadcast),
+				InputMulticast: number(r, interfaceInputMulticast),
+				Speed:          field(r, interfaceSpeed),
+			}
+
+			// "administratively down" (Cisco) and "*down" (Huawei) mark interfaces shut down
+			if item.AdminStatus == "" {
+				link := strings.ToLower(field(r, interfaceLink))
+				item.AdminStatus = "up"
+				if strings.Contains(link, "admin") || strings.HasPrefix(link, "*") {
+					item.AdminStatus = "down"
+				}
+			}
+			if strings.HasPrefix(strings.ToLower(field(r, interfaceOper)), "up") {
+				item.OperStatus = "up"
+			}
+
+			items = append(items, item)
+		}
+
+		return items, nil
+	}
+}
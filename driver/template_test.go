@@ -0,0 +1,45 @@
+package driver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shenjler/ssh_ping_exporter/textfsm"
+)
+
+func TestUseTemplateEnvironmentKeepsMore(t *testing.T) {
+	tmpl, err := textfsm.Parse(strings.NewReader(`Value SENSOR (\S+)
+Value TEMPERATURE (\d+)
+
+Start
+  ^${SENSOR}\s+${TEMPERATURE}C -> Record
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	power := &EnvironmentCommand{Command: "show power", Parse: func(string) ([]EnvironmentItem, error) { return nil, nil }}
+	d := &Driver{
+		OS:          "TEST",
+		Environment: &EnvironmentCommand{Command: "show temperature", More: []*EnvironmentCommand{power}},
+	}
+
+	err = useTemplate(d, TemplateEnvironment, "", tmpl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Environment.Command != "show temperature" {
+		t.Errorf("got command %q, expected the command of the driver", d.Environment.Command)
+	}
+	if len(d.Environment.More) != 1 || d.Environment.More[0] != power {
+		t.Errorf("further commands of the driver were dropped: %v", d.Environment.More)
+	}
+
+	items, err := d.Environment.Parse("inlet 31C\noutlet 40C")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].Name != "inlet" || items[0].Temperature != 31 || !items[0].IsTemp {
+		t.Errorf("unexpected items %+v", items)
+	}
+}
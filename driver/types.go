@@ -0,0 +1,85 @@
+package driver
+
+// BgpSession is a BGP session as reported by the device
+type BgpSession struct {
+	IP               string
+	Asn              string
+	Up               bool
+	ReceivedPrefixes float64
+	InputMessages    float64
+	OutputMessages   float64
+}
+
+// EnvironmentItem is a temperature sensor or a power supply
+type EnvironmentItem struct {
+	Name        string
+	Status      string
+	OK          bool
+	IsTemp      bool
+	Temperature float64
+}
+
+// VersionFact is the version of the running OS
+type VersionFact struct {
+	Version string
+}
+
+// MemoryFact is the usage of a memory pool
+type MemoryFact struct {
+	Type  string
+	Total float64
+	Used  float64
+	Free  float64
+}
+
+// CPUFact is the CPU utilization
+type CPUFact struct {
+	FiveSeconds float64
+	Interrupts  float64
+	OneMinute   float64
+	FiveMinutes float64
+}
+
+// Interface is an interface with its counters
+type Interface struct {
+	Name        string
+	MacAddress  string
+	Description string
+
+	AdminStatus string
+	OperStatus  string
+
+	InputErrors  float64
+	OutputErrors float64
+
+	InputDrops  float64
+	OutputDrops float64
+
+	InputBytes  float64
+	OutputBytes float64
+
+	InputBroadcast float64
+	InputMulticast float64
+
+	Speed string
+}
+
+// Optics are the optical signals of a transceiver
+type Optics struct {
+	RxPower float64
+	TxPower float64
+}
+
+// Icmp is the result of a ping
+type Icmp struct {
+	Name   string
+	Target string
+	Source string
+
+	PingStatus string
+
+	PacketLoss float64
+	RttMin     float64
+	RttMax     float64
+	RttAvg     float64
+}
@@ -1,8 +1,6 @@
 package environment
 
 import (
-	"log"
-
 	"github.com/shenjler/ssh_ping_exporter/rpc"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -21,6 +19,14 @@ func init() {
 	temperaturesDesc = prometheus.NewDesc(prefix+"sensor_temp", "Sensor temperatures", l, nil)
 	l = append(l, "status")
 	powerSupplyDesc = prometheus.NewDesc(prefix+"power_up", "Status of power supplies (1 OK, 0 Something is wrong)", l, nil)
+
+	collector.Register(&collector.Registration{
+		Name: "environment",
+		Help: "Environment (temperatures, state of power supply)",
+		New: func(interface{}) (collector.RPCCollector, error) {
+			return NewCollector(), nil
+		},
+	})
 }
 
 type environmentCollector struct {
@@ -43,21 +49,20 @@ func (*environmentCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 // Collect collects metrics from Cisco
-func (c *environmentCollector) Collect(client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error {
-	out, err := client.RunCommand("show environment")
-	if err != nil {
-		return err
+func (c *environmentCollector) Collect(sc *collector.ScrapeContext, client *rpc.Client, ch chan<- prometheus.Metric) error {
+	cmd := client.Driver().Environment
+	if cmd == nil {
+		sc.Logger.Debugf("Environment is not supported (%s)", client.OSType)
+		return nil
 	}
-	items, err := c.Parse(client.OSType, out)
+
+	items, err := cmd.Run(client.BatchRunner(sc.Context))
 	if err != nil {
-		if client.Debug {
-			log.Printf("Parse environment for %s: %s\n", labelValues[0], err.Error())
-		}
-		return nil
+		return err
 	}
 
 	for _, item := range items {
-		l := append(labelValues, item.Name)
+		l := sc.LabelValues(item.Name)
 		if item.IsTemp {
 			ch <- prometheus.MustNewConstMetric(temperaturesDesc, prometheus.GaugeValue, float64(item.Temperature), l...)
 		} else {
@@ -1,8 +1,7 @@
 package facts
 
 import (
-	"log"
-
+	"github.com/shenjler/ssh_ping_exporter/driver"
 	"github.com/shenjler/ssh_ping_exporter/rpc"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -34,6 +33,14 @@ func init() {
 	cpuFiveSecondsDesc = prometheus.NewDesc(prefix+"cpu_five_seconds_percent", "CPU utilization for five seconds", l, nil)
 	cpuInterruptsDesc = prometheus.NewDesc(prefix+"cpu_interrupt_percent", "Interrupt percentage", l, nil)
 	cpuFiveMinutesDesc = prometheus.NewDesc(prefix+"cpu_five_minutes_percent", "CPU utilization for five minutes", l, nil)
+
+	collector.Register(&collector.Registration{
+		Name: "facts",
+		Help: "System information (OS version, memory: total/used/free, cpu: 5s/1m/5m/interrupts)",
+		New: func(interface{}) (collector.RPCCollector, error) {
+			return NewCollector(), nil
+		},
+	})
 }
 
 type factsCollector struct {
@@ -58,32 +65,42 @@ func (*factsCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 // CollectVersion collects version informations from Cisco
-func (c *factsCollector) CollectVersion(client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error {
-	out, err := client.RunCommand("show version")
+func (c *factsCollector) CollectVersion(sc *collector.ScrapeContext, client *rpc.Client, ch chan<- prometheus.Metric) error {
+	facts := client.Driver().Facts
+	if facts == nil || facts.Version == nil {
+		sc.Logger.Debugf("Version is not supported (%s)", client.OSType)
+		return nil
+	}
+	out, err := client.RunCommand(sc.Context, facts.Version.Command)
 	if err != nil {
 		return err
 	}
-	item, err := c.ParseVersion(client.OSType, out)
+	item, err := facts.Version.Parse(out)
 	if err != nil {
-		return err
+		return &driver.ParseError{Command: facts.Version.Command, Err: err}
 	}
-	l := append(labelValues, item.Version)
+	l := sc.LabelValues(item.Version)
 	ch <- prometheus.MustNewConstMetric(versionDesc, prometheus.GaugeValue, 1, l...)
 	return nil
 }
 
 // CollectMemory collects memory informations from Cisco
-func (c *factsCollector) CollectMemory(client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error {
-	out, err := client.RunCommand("show process memory")
+func (c *factsCollector) CollectMemory(sc *collector.ScrapeContext, client *rpc.Client, ch chan<- prometheus.Metric) error {
+	facts := client.Driver().Facts
+	if facts == nil || facts.Memory == nil {
+		sc.Logger.Debugf("Memory is not supported (%s)", client.OSType)
+		return nil
+	}
+	out, err := client.RunCommand(sc.Context, facts.Memory.Command)
 	if err != nil {
 		return err
 	}
-	items, err := c.ParseMemory(client.OSType, out)
+	items, err := facts.Memory.Parse(out)
 	if err != nil {
-		return err
+		return &driver.ParseError{Command: facts.Memory.Command, Err: err}
 	}
 	for _, item := range items {
-		l := append(labelValues, item.Type)
+		l := sc.LabelValues(item.Type)
 		ch <- prometheus.MustNewConstMetric(memoryTotalDesc, prometheus.GaugeValue, item.Total, l...)
 		ch <- prometheus.MustNewConstMetric(memoryUsedDesc, prometheus.GaugeValue, item.Used, l...)
 		ch <- prometheus.MustNewConstMetric(memoryFreeDesc, prometheus.GaugeValue, item.Free, l...)
@@ -92,35 +109,35 @@ func (c *factsCollector) CollectMemory(client *rpc.Client, ch chan<- prometheus.
 }
 
 // CollectCPU collects cpu informations from Cisco
-func (c *factsCollector) CollectCPU(client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error {
-	out, err := client.RunCommand("show process cpu")
+func (c *factsCollector) CollectCPU(sc *collector.ScrapeContext, client *rpc.Client, ch chan<- prometheus.Metric) error {
+	facts := client.Driver().Facts
+	if facts == nil || facts.CPU == nil {
+		sc.Logger.Debugf("CPU is not supported (%s)", client.OSType)
+		return nil
+	}
+	out, err := client.RunCommand(sc.Context, facts.CPU.Command)
 	if err != nil {
 		return err
 	}
-	item, err := c.ParseCPU(client.OSType, out)
+	item, err := facts.CPU.Parse(out)
 	if err != nil {
-		return err
+		return &driver.ParseError{Command: facts.CPU.Command, Err: err}
 	}
-	ch <- prometheus.MustNewConstMetric(cpuOneMinuteDesc, prometheus.GaugeValue, item.OneMinute, labelValues...)
-	ch <- prometheus.MustNewConstMetric(cpuFiveSecondsDesc, prometheus.GaugeValue, item.FiveSeconds, labelValues...)
-	ch <- prometheus.MustNewConstMetric(cpuInterruptsDesc, prometheus.GaugeValue, item.Interrupts, labelValues...)
-	ch <- prometheus.MustNewConstMetric(cpuFiveMinutesDesc, prometheus.GaugeValue, item.FiveMinutes, labelValues...)
+	ch <- prometheus.MustNewConstMetric(cpuOneMinuteDesc, prometheus.GaugeValue, item.OneMinute, sc.LabelValues()...)
+	ch <- prometheus.MustNewConstMetric(cpuFiveSecondsDesc, prometheus.GaugeValue, item.FiveSeconds, sc.LabelValues()...)
+	ch <- prometheus.MustNewConstMetric(cpuInterruptsDesc, prometheus.GaugeValue, item.Interrupts, sc.LabelValues()...)
+	ch <- prometheus.MustNewConstMetric(cpuFiveMinutesDesc, prometheus.GaugeValue, item.FiveMinutes, sc.LabelValues()...)
 	return nil
 }
 
-// Collect collects metrics from Cisco
-func (c *factsCollector) Collect(client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error {
-	err := c.CollectVersion(client, ch, labelValues)
-	if client.Debug && err != nil {
-		log.Printf("CollectVersion for %s: %s\n", labelValues[0], err.Error())
-	}
-	err = c.CollectMemory(client, ch, labelValues)
-	if client.Debug && err != nil {
-		log.Printf("CollectMemory for %s: %s\n", labelValues[0], err.Error())
+// Collect collects metrics from Cisco. All facts are collected even if one fails, the first error is returned.
+func (c *factsCollector) Collect(sc *collector.ScrapeContext, client *rpc.Client, ch chan<- prometheus.Metric) error {
+	var first error
+	for _, collect := range []func(*collector.ScrapeContext, *rpc.Client, chan<- prometheus.Metric) error{c.CollectVersion, c.CollectMemory, c.CollectCPU} {
+		err := collect(sc, client, ch)
+		if err != nil && first == nil {
+			first = err
+		}
 	}
-	err = c.CollectCPU(client, ch, labelValues)
-	if client.Debug && err != nil {
-		log.Printf("CollectCPU for %s: %s\n", labelValues[0], err.Error())
-	}
-	return nil
+	return first
 }
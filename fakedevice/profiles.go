@@ -0,0 +1,233 @@
+package fakedevice
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+var pingRegexp = regexp.MustCompile(`^ping(?:\s+-c\s+(\d+))?\s+(\S+)$`)
+
+// Linux returns a Linux host answering uname and ping
+func Linux(hostname string) Device {
+	return Device{
+		Prompt: "\x1b[01;32mroot@" + hostname + "\x1b[00m:~# ",
+		MOTD: "Welcome to Ubuntu 20.04.6 LTS (GNU/Linux 5.4.0-150-generic x86_64)\n\n" +
+			"Last login: Mon Oct 12 09:14:02 2026 from 10.0.0.10",
+		Echo: true,
+		Responses: map[string]string{
+			"uname -a": "Linux " + hostname + " 5.4.0-150-generic #167-Ubuntu SMP Mon May 15 17:35:05 UTC 2023 x86_64 x86_64 x86_64 GNU/Linux",
+			"hostname": hostname,
+		},
+		Handler:        linuxPing,
+		UnknownCommand: "-bash: command not found",
+	}
+}
+
+func linuxPing(cmd string) (string, bool) {
+	m := pingRegexp.FindStringSubmatch(cmd)
+	if m == nil {
+		return "", false
+	}
+
+	count := 4
+	if m[1] != "" {
+		count, _ = strconv.Atoi(m[1])
+	}
+	dest := m[2]
+
+	out := fmt.Sprintf("PING %s (192.0.2.1) 56(84) bytes of data.\n", dest)
+	for i := 1; i <= count; i++ {
+		out += fmt.Sprintf("64 bytes from 192.0.2.1: icmp_seq=%d ttl=54 time=%d.%d ms\n", i, 30+i, i*3)
+	}
+	out += fmt.Sprintf("\n--- %s ping statistics ---\n", dest)
+	out += fmt.Sprintf("%d packets transmitted, %d received, 0%% packet loss, time %dms\n", count, count, (count-1)*1001)
+	out += "rtt min/avg/max/mdev = 31.300/32.600/33.900/1.100 ms"
+
+	return out, true
+}
+
+// Cisco returns a Cisco IOS XE router with a pager and answers for the common show commands
+func Cisco(hostname string) Device {
+	return Device{
+		Prompt:         hostname + "#",
+		Banner:         "*** Authorized access only ***\n",
+		MOTD:           "\n" + hostname + " - lab router",
+		Echo:           true,
+		PagerLines:     24,
+		PagerPrompt:    " --More-- ",
+		PagerDisable:   "terminal length 0",
+		Enable:         "enable",
+		UnknownCommand: "                   ^\n% Invalid input detected at '^' marker.",
+		Responses: map[string]string{
+			"show version":                           ciscoShowVersion(hostname),
+			"show interface":                         ciscoShowInterface,
+			"show vlans":                             ciscoShowVlans,
+			"show bgp all summary":                   ciscoShowBGPSummary,
+			"show environment":                       ciscoShowEnvironment,
+			"ping 192.0.2.1 repeat 3":                ciscoPing,
+			"show running-config | include hostname": "hostname " + hostname,
+		},
+	}
+}
+
+func ciscoShowVersion(hostname string) string {
+	return `Cisco IOS XE Software, Version 16.09.04
+Cisco IOS Software [Fuji], ASR1000 Software (X86_64_LINUX_IOSD-UNIVERSALK9-M), Version 16.9.4, RELEASE SOFTWARE (fc2)
+Technical Support: http://www.cisco.com/techsupport
+Copyright (c) 1986-2019 by Cisco Systems, Inc.
+
+ROM: IOS-XE ROMMON
+
+` + hostname + ` uptime is 12 weeks, 3 days, 4 hours, 5 minutes
+Uptime for this control processor is 12 weeks, 3 days, 4 hours, 7 minutes
+System returned to ROM by reload
+System image file is "bootflash:asr1001x-universalk9.16.09.04.SPA.bin"
+
+cisco ASR1001-X (1NG) processor (revision 1NG) with 3755935K/6147K bytes of memory.
+Processor board ID FXS2211Q1AB
+4 Gigabit Ethernet interfaces
+32768K bytes of non-volatile configuration memory.
+
+Configuration register is 0x2102`
+}
+
+const ciscoShowInterface = `GigabitEthernet0/0/0 is up, line protocol is up
+  Hardware is BUILT-IN-EPA-8x1G, address is 00a3.d1f4.2a00 (bia 00a3.d1f4.2a00)
+  Description: uplink core1
+  Internet address is 10.0.0.1/30
+  MTU 1500 bytes, BW 1000000 Kbit/sec, DLY 10 usec,
+     reliability 255/255, txload 1/255, rxload 1/255
+  Full Duplex, 1000Mbps, link type is auto, media type is SX
+  Input queue: 0/375/0/0 (size/max/drops/flushes); Total output drops: 0
+  5 minute input rate 2000 bits/sec, 2 packets/sec
+  5 minute output rate 1000 bits/sec, 1 packets/sec
+     1234567 packets input, 987654321 bytes, 0 no buffer
+     Received 1234 broadcasts (0 IP multicasts)
+     0 runts, 0 giants, 0 throttles
+     3 input errors, 0 CRC, 0 frame, 0 overrun, 0 ignored
+     2345678 packets output, 876543210 bytes, 0 underruns
+     0 output errors, 0 collisions, 1 interface resets
+GigabitEthernet0/0/1 is administratively down, line protocol is down
+  Hardware is BUILT-IN-EPA-8x1G, address is 00a3.d1f4.2a01 (bia 00a3.d1f4.2a01)
+  MTU 1500 bytes, BW 1000000 Kbit/sec, DLY 10 usec,
+     reliability 255/255, txload 1/255, rxload 1/255
+  Full Duplex, 1000Mbps, link type is auto, media type is unknown media type
+  Input queue: 0/375/0/0 (size/max/drops/flushes); Total output drops: 0
+  5 minute input rate 0 bits/sec, 0 packets/sec
+  5 minute output rate 0 bits/sec, 0 packets/sec
+     0 packets input, 0 bytes, 0 no buffer
+     Received 0 broadcasts (0 IP multicasts)
+     0 runts, 0 giants, 0 throttles
+     0 input errors, 0 CRC, 0 frame, 0 overrun, 0 ignored
+     0 packets output, 0 bytes, 0 underruns
+     0 output errors, 0 collisions, 0 interface resets`
+
+const ciscoShowVlans = `Virtual LAN ID:  10 (IEEE 802.1Q Encapsulation)
+
+   vLAN Trunk Interface:   GigabitEthernet0/0/0.10
+
+   Protocols Configured:   Address:              Received:        Transmitted:
+           IP              10.10.0.1                  1200               1100
+
+   VLAN trunk interfaces for VLAN ID 10:
+
+GigabitEthernet0/0/0.10 (10)
+
+        IP:  10.10.0.1
+
+    Total 1200 packets, 345678 bytes input
+    Total 1100 packets, 234567 bytes output`
+
+const ciscoShowBGPSummary = `For address family: IPv4 Unicast
+BGP router identifier 10.255.0.1, local AS number 65000
+BGP table version is 42, main routing table version 42
+
+Neighbor        V           AS MsgRcvd MsgSent   TblVer  InQ OutQ Up/Down  State/PfxRcd
+10.0.0.2        4        65001    1234    1235       42    0    0 1d02h           12
+10.0.0.6        4        65002       0       0        1    0    0 never    Idle`
+
+const ciscoShowEnvironment = `Number of Critical alarms:  0
+Number of Major alarms:     0
+Number of Minor alarms:     0
+
+ Slot    Sensor       Current State       Reading
+ ----    ------       -------------       -------
+ P0    PEM Iout       Normal              5 A
+ P0    PEM Vout       Normal              12 V DC
+ R0    Temp: Inlet    Normal              31 Celsius
+ R0    Temp: Outlet   Normal              42 Celsius`
+
+const ciscoPing = `Type escape sequence to abort.
+Sending 3, 100-byte ICMP Echos to 192.0.2.1, timeout is 2 seconds:
+!!!
+Success rate is 100 percent (3/3), round-trip min/avg/max = 1/2/4 ms`
+
+// Huawei returns a Huawei VRP switch with a pager and answers for the common display commands
+func Huawei(hostname string) Device {
+	return Device{
+		Prompt: "<" + hostname + ">",
+		MOTD: "\nInfo: The max number of VTY users is 5, the number of current VTY users online is 1.\n" +
+			"      The current login time is 2026-10-18 10:00:00+08:00.\n" +
+			"      The last login time is 2026-10-17 09:30:12+08:00 from 10.0.0.10 through SSH.",
+		Echo:           true,
+		PagerLines:     24,
+		PagerPrompt:    "  ---- More ----",
+		PagerDisable:   "screen-length 0 temporary",
+		UnknownCommand: "              ^\nError: Unrecognized command found at '^' position.",
+		Responses: map[string]string{
+			"display version":         huaweiDisplayVersion(hostname),
+			"display interface brief": huaweiDisplayInterfaceBrief,
+			"display bgp peer":        huaweiDisplayBGPPeer,
+			"ping -c 3 192.0.2.1":     huaweiPing,
+		},
+	}
+}
+
+func huaweiDisplayVersion(hostname string) string {
+	return `Huawei Versatile Routing Platform Software
+VRP (R) software, Version 8.180 (CE6850 V200R005C10SPC800)
+Copyright (C) 2012-2018 Huawei Technologies Co., Ltd.
+HUAWEI CE6850-48S6Q-HI uptime is 10 days, 2 hours, 3 minutes
+Patch Version: V200R005SPH012
+
+CE6850-48S6Q-HI(Master) 1 : uptime is  10 days, 2 hours, 2 minutes
+        StartupTime 2026/10/08   08:00:12
+Memory    Size    : 2048 M bytes
+Flash     Size    : 1024 M bytes
+Sysname           : ` + hostname
+}
+
+const huaweiDisplayInterfaceBrief = `PHY: Physical
+*down: administratively down
+^down: standby
+(l): loopback
+(s): spoofing
+(b): BFD down
+(e): ETHOAM down
+(d): Dampening Suppressed
+InUti/OutUti: input utility/output utility
+Interface                   PHY   Protocol  InUti OutUti   inErrors  outErrors
+10GE1/0/1                   up    up        0.01%  0.01%          0          0
+10GE1/0/2                   *down down         0%     0%          0          0
+MEth0/0/0                   up    up        0.01%  0.01%          0          0`
+
+const huaweiDisplayBGPPeer = `
+ BGP local router ID : 10.255.0.2
+ Local AS number : 65000
+ Total number of peers : 2                 Peers in established state : 1
+
+  Peer            V          AS  MsgRcvd  MsgSent  OutQ  Up/Down       State  PrefRcv
+  10.0.0.1        4       65001     1234     1235     0 0026h02m Established       12
+  10.0.0.9        4       65002        0        0     0 0000h00m Idle               0`
+
+const huaweiPing = `  PING 192.0.2.1: 56  data bytes, press CTRL_C to break
+    Reply from 192.0.2.1: bytes=56 Sequence=1 ttl=254 time=2 ms
+    Reply from 192.0.2.1: bytes=56 Sequence=2 ttl=254 time=1 ms
+    Reply from 192.0.2.1: bytes=56 Sequence=3 ttl=254 time=1 ms
+
+  --- 192.0.2.1 ping statistics ---
+    3 packet(s) transmitted
+    3 packet(s) received
+    0.00% packet loss
+    round-trip min/avg/max = 1/1/2 ms`
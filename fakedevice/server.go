@@ -0,0 +1,385 @@
+// Package fakedevice provides an in-process SSH server emulating the CLI of a network device.
+// It is meant for integration tests and demos of the exporter without real devices.
+package fakedevice
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+// Handler produces the output of commands not listed in Device.Responses
+type Handler func(cmd string) (output string, ok bool)
+
+// Device describes the emulated device
+type Device struct {
+	// Prompt is printed after login and after every command
+	Prompt string
+	// Banner is sent as SSH banner before authentication
+	Banner string
+	// MOTD is printed after login before the first prompt
+	MOTD string
+	// Username and Password are required to log in, an empty username accepts any credentials
+	Username string
+	Password string
+	// Echo sends every command back as a terminal would
+	Echo bool
+	// PagerLines splits longer outputs into pages separated by PagerPrompt (0 = no pager)
+	PagerLines  int
+	PagerPrompt string
+	// PagerDisable is the command turning the pager off for the rest of the session
+	PagerDisable string
+	// Enable is the privilege escalation command, it asks for EnablePassword if one is set
+	Enable         string
+	EnablePassword string
+	// RefusePTY rejects PTY requests
+	RefusePTY bool
+	// Responses maps commands to their output
+	Responses map[string]string
+	// Handler is asked for commands missing in Responses
+	Handler Handler
+	// UnknownCommand is printed for commands neither in Responses nor known by Handler
+	UnknownCommand string
+}
+
+// Server is an SSH server emulating one device
+type Server struct {
+	device   Device
+	config   *ssh.ServerConfig
+	listener net.Listener
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	conns    map[net.Conn]struct{}
+}
+
+// Start starts a server for device listening on addr (e.g. "127.0.0.1:0")
+func Start(addr string, device Device) (*Server, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{device: device, conns: make(map[net.Conn]struct{})}
+	s.config = &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if device.Username == "" || (conn.User() == device.Username && string(password) == device.Password) {
+				return nil, nil
+			}
+			return nil, errors.New("access denied")
+		},
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if device.Username == "" {
+				return nil, nil
+			}
+			return nil, errors.New("access denied")
+		},
+		BannerCallback: func(conn ssh.ConnMetadata) string {
+			return device.Banner
+		},
+	}
+	s.config.AddHostKey(signer)
+
+	s.listener, err = net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+
+	return s, nil
+}
+
+// Addr returns the address the server listens on
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close stops accepting connections, disconnects all clients and waits for the sessions to end
+func (s *Server) Close() error {
+	err := s.listener.Close()
+
+	s.mu.Lock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.wg.Add(1)
+		go s.handleConn(conn)
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer s.wg.Done()
+	defer conn.Close()
+
+	s.mu.Lock()
+	s.conns[conn] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+	}()
+
+	sshConn, chans, reqs, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		return
+	}
+	defer sshConn.Close()
+
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "only sessions are supported")
+			continue
+		}
+
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			log.Printf("fakedevice: could not accept channel: %s", err)
+			continue
+		}
+
+		s.wg.Add(1)
+		go s.handleSession(channel, requests)
+	}
+}
+
+func (s *Server) handleSession(channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer s.wg.Done()
+	defer channel.Close()
+
+	for req := range requests {
+		switch req.Type {
+		case "pty-req":
+			req.Reply(!s.device.RefusePTY, nil)
+		case "shell":
+			req.Reply(true, nil)
+			go func() {
+				for req := range requests {
+					req.Reply(false, nil)
+				}
+			}()
+			newShell(s.device, channel).run()
+			channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{0}))
+			return
+		default:
+			req.Reply(req.Type == "env", nil)
+		}
+	}
+}
+
+// shell is the CLI session of one client
+type shell struct {
+	device  Device
+	channel ssh.Channel
+	pager   bool
+	input   []byte
+}
+
+func newShell(device Device, channel ssh.Channel) *shell {
+	return &shell{
+		device:  device,
+		channel: channel,
+		pager:   device.PagerLines > 0,
+	}
+}
+
+func (s *shell) run() {
+	if s.device.MOTD != "" {
+		s.write(s.device.MOTD + "\n")
+	}
+	s.write(s.device.Prompt)
+
+	for {
+		line, err := s.readLine()
+		if err != nil {
+			return
+		}
+
+		cmd := strings.TrimSpace(line)
+		if s.device.Echo {
+			s.write(line + "\n")
+		}
+		if cmd == "exit" || cmd == "quit" {
+			return
+		}
+
+		if cmd != "" && cmd == s.device.Enable {
+			err = s.enable()
+			if err != nil {
+				return
+			}
+		} else if cmd != "" {
+			err = s.writePaged(s.output(cmd))
+			if err != nil {
+				return
+			}
+		}
+		s.write(s.device.Prompt)
+	}
+}
+
+// enable asks for the enable password like IOS does, three times at most
+func (s *shell) enable() error {
+	if s.device.EnablePassword == "" {
+		return nil
+	}
+
+	for i := 0; i < 3; i++ {
+		s.write("Password: ")
+		password, err := s.readLine()
+		if err != nil {
+			return err
+		}
+		s.write("\n")
+		if password == s.device.EnablePassword {
+			return nil
+		}
+	}
+	s.write("% Bad secrets\n\n")
+
+	return nil
+}
+
+// output returns the output of cmd, ending with a newline unless empty
+func (s *shell) output(cmd string) string {
+	if s.device.PagerDisable != "" && cmd == s.device.PagerDisable {
+		s.pager = false
+		return ""
+	}
+
+	out, found := s.device.Responses[cmd]
+	if !found && s.device.Handler != nil {
+		out, found = s.device.Handler(cmd)
+	}
+	if !found {
+		out = s.device.UnknownCommand
+		if out == "" {
+			out = fmt.Sprintf("%% Unknown command: %s", cmd)
+		}
+	}
+
+	if out != "" && !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+
+	return out
+}
+
+// writePaged writes out page by page if the pager is active. Any key shows the next page, q aborts the output.
+func (s *shell) writePaged(out string) error {
+	if !s.pager {
+		s.write(out)
+		return nil
+	}
+
+	lines := strings.SplitAfter(out, "\n")
+	for len(lines) > 0 {
+		n := s.device.PagerLines
+		if n > len(lines) {
+			n = len(lines)
+		}
+		s.write(strings.Join(lines[:n], ""))
+		lines = lines[n:]
+
+		if len(lines) == 0 || lines[0] == "" {
+			return nil
+		}
+
+		s.write(s.device.PagerPrompt)
+		key, err := s.readByte()
+		if err != nil {
+			return err
+		}
+		s.write("\r" + strings.Repeat(" ", len(s.device.PagerPrompt)) + "\r")
+		if key == 'q' {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// readLine reads up to the next CR or LF, a CR LF sequence counts as one line break
+func (s *shell) readLine() (string, error) {
+	for {
+		if i := bytes.IndexAny(s.input, "\r\n"); i >= 0 {
+			line := string(s.input[:i])
+			if s.input[i] == '\r' && i+1 < len(s.input) && s.input[i+1] == '\n' {
+				i++
+			}
+			s.input = s.input[i+1:]
+
+			return line, nil
+		}
+
+		err := s.fill()
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+func (s *shell) readByte() (byte, error) {
+	if len(s.input) == 0 {
+		err := s.fill()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	b := s.input[0]
+	s.input = s.input[1:]
+
+	return b, nil
+}
+
+func (s *shell) fill() error {
+	buf := make([]byte, 1024)
+	n, err := s.channel.Read(buf)
+	s.input = append(s.input, buf[:n]...)
+	if n > 0 {
+		return nil
+	}
+	if err == nil {
+		err = io.ErrNoProgress
+	}
+
+	return err
+}
+
+// write sends text to the client with terminal line endings
+func (s *shell) write(text string) {
+	s.channel.Write([]byte(strings.Replace(text, "\n", "\r\n", -1)))
+}
@@ -1,10 +1,9 @@
 package icmp
 
 import (
-	"log"
-
 	"math"
 
+	"github.com/shenjler/ssh_ping_exporter/driver"
 	"github.com/shenjler/ssh_ping_exporter/rpc"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -27,15 +26,40 @@ func init() {
 	pingStatusDesc = prometheus.NewDesc(prefix+"status", "Status of ping, 0-down、1-up. ", l, nil)
 	jitterDesc = prometheus.NewDesc(prefix+"jitter", "The jitter of ping, max-min rtt is jitter time, unit is ms.", l, nil)
 
+	collector.Register(&collector.Registration{
+		Name:           "icmp",
+		Help:           "Ping (packet loss, rtt, jitter) from the device to the destination of the scrape",
+		DefaultEnabled: true,
+		NewConfig: func() interface{} {
+			return &Config{Dest: DefaultDest}
+		},
+		New: func(cfg interface{}) (collector.RPCCollector, error) {
+			return NewCollectorWithConfig(cfg.(*Config)), nil
+		},
+	})
+}
+
+// DefaultDest is pinged if neither the scrape nor the config name a destination
+const DefaultDest = "baidu.com"
+
+// Config is the config of the icmp collector
+type Config struct {
+	// Dest is pinged if the scrape does not name a destination
+	Dest string `yaml:"dest,omitempty"`
 }
 
 type icmpCollector struct {
-	// dest string
+	dest string
 }
 
 // NewCollector creates a new collector
 func NewCollector() collector.RPCCollector {
-	return &icmpCollector{}
+	return NewCollectorWithConfig(&Config{Dest: DefaultDest})
+}
+
+// NewCollectorWithConfig creates a new collector pinging the destination of cfg unless the scrape names one
+func NewCollectorWithConfig(cfg *Config) collector.RPCCollector {
+	return &icmpCollector{dest: cfg.Dest}
 }
 
 // Name returns the name of the collector
@@ -48,28 +72,36 @@ func (*icmpCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- packetLossDesc
 	ch <- rttAvgDesc
 	ch <- pingStatusDesc
+	ch <- jitterDesc
 }
 
-func (c *icmpCollector) Collect(client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error {
-	return c.CollectByDest(client, ch, labelValues, "www.baidu.com")
-}
-
-// Collect collects metrics from Cisco
-func (c *icmpCollector) CollectByDest(client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string, dest string) error {
-	out, err := client.RunCommand("ping -c 3 " + dest)
+// Collect pings the destination of the scrape, or the configured one, from the device
+func (c *icmpCollector) Collect(sc *collector.ScrapeContext, client *rpc.Client, ch chan<- prometheus.Metric) error {
+	cmd := client.Driver().Ping
+	if cmd == nil {
+		sc.Logger.Debugf("Ping is not supported (%s)", client.OSType)
+		return nil
+	}
+	dest := sc.Probe.Dest
+	if dest == "" {
+		dest = c.dest
+	}
+	if dest == "" {
+		sc.Logger.Debugf("No ping destination")
+		return nil
+	}
 
+	command := cmd.Command(dest)
+	out, err := client.RunCommand(sc.Context, command)
 	if err != nil {
 		return err
 	}
-	item, err := c.Parse(client.OSType, out)
+	item, err := cmd.Parse(out)
 	if err != nil {
-		if client.Debug {
-			log.Printf("Parse ping for %s: %s\n", labelValues[0], err.Error())
-		}
-		return nil
+		return &driver.ParseError{Command: command, Err: err}
 	}
 
-	l := append(labelValues, item.Target)
+	l := sc.LabelValues(item.Target)
 	ch <- prometheus.MustNewConstMetric(packetLossDesc, prometheus.GaugeValue, float64(item.PacketLoss), l...)
 
 	if item.PingStatus == "up" {
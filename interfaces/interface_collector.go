@@ -1,8 +1,6 @@
 package interfaces
 
 import (
-	"log"
-
 	"github.com/shenjler/ssh_ping_exporter/rpc"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -38,6 +36,14 @@ func init() {
 	adminStatusDesc = prometheus.NewDesc(prefix+"admin_up", "Admin operational status", l, nil)
 	operStatusDesc = prometheus.NewDesc(prefix+"up", "Interface operational status", l, nil)
 	errorStatusDesc = prometheus.NewDesc(prefix+"error_status", "Admin and operational status differ", l, nil)
+
+	collector.Register(&collector.Registration{
+		Name: "interfaces",
+		Help: "Interfaces (transmitted/received: bytes/errors/drops, admin/oper state)",
+		New: func(interface{}) (collector.RPCCollector, error) {
+			return NewCollector(), nil
+		},
+	})
 }
 
 type interfaceCollector struct {
@@ -69,43 +75,20 @@ func (*interfaceCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 // Collect collects metrics from Cisco
-func (c *interfaceCollector) Collect(client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error {
-	out, err := client.RunCommand("show interface")
-	if err != nil {
-		return err
-	}
-	items, err := c.Parse(client.OSType, out)
-	if err != nil {
-		if client.Debug {
-			log.Printf("Parse interfaces for %s: %s\n", labelValues[0], err.Error())
-		}
+func (c *interfaceCollector) Collect(sc *collector.ScrapeContext, client *rpc.Client, ch chan<- prometheus.Metric) error {
+	cmd := client.Driver().Interfaces
+	if cmd == nil {
+		sc.Logger.Debugf("Interfaces are not supported (%s)", client.OSType)
 		return nil
 	}
-	if client.OSType == rpc.IOSXE {
-		out, err := client.RunCommand("show vlans")
-		if err != nil {
-			return err
-		}
-		vlans, err := c.ParseVlans(client.OSType, out)
-		if err != nil {
-			if client.Debug {
-				log.Printf("Parse vlans for %s: %s\n", labelValues[0], err.Error())
-			}
-			return nil
-		}
-		for _, vlan := range vlans {
-			for i, item := range items {
-				if item.Name == vlan.Name {
-					items[i].InputBytes = vlan.InputBytes
-					items[i].OutputBytes = vlan.OutputBytes
-					break
-				}
-			}
-		}
+
+	items, err := cmd.Run(client.Runner(sc.Context))
+	if err != nil {
+		return err
 	}
 
 	for _, item := range items {
-		l := append(labelValues, item.Name, item.Description, item.MacAddress, item.Speed)
+		l := sc.LabelValues(item.Name, item.Description, item.MacAddress, item.Speed)
 
 		errorStatus := 0
 		if item.AdminStatus != item.OperStatus {
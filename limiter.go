@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/shenjler/ssh_ping_exporter/config"
+	"github.com/shenjler/ssh_ping_exporter/connector"
+)
+
+// sessionLimiter bounds the number of concurrent SSH sessions globally and per device.
+// Sessions are counted across reloads, so sessions started before a reload count against the new limits.
+type sessionLimiter struct {
+	mu      sync.Mutex
+	cfg     *config.Config
+	active  int
+	changed chan struct{}
+	devices map[string]*deviceLimiter
+}
+
+type deviceLimiter struct {
+	active    int
+	loginMu   sync.Mutex
+	lastLogin time.Time
+	queued    uint64
+	rejected  uint64
+}
+
+func newSessionLimiter() *sessionLimiter {
+	return &sessionLimiter{
+		changed: make(chan struct{}),
+		devices: make(map[string]*deviceLimiter),
+	}
+}
+
+// configure applies the limits of cfg. Sessions started before keep their slots until released.
+func (l *sessionLimiter) configure(cfg *config.Config) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.cfg = cfg
+	l.notify()
+}
+
+// acquire waits until a session to device may be opened. The returned func must be called when the session is closed.
+func (l *sessionLimiter) acquire(ctx context.Context, device *connector.Device) (release func(), wait time.Duration, err error) {
+	t := time.Now()
+	d := l.limiterForDevice(device)
+
+	// a session is counted as queued once, whichever of the waits it had to do
+	queued := false
+	err = l.take(ctx, d, device, &queued)
+	if err != nil {
+		return nil, time.Since(t), err
+	}
+
+	err = d.awaitLogin(ctx, l.minLoginInterval(device), &queued)
+	if err != nil {
+		l.release(d)
+		return nil, time.Since(t), err
+	}
+
+	return func() { l.release(d) }, time.Since(t), nil
+}
+
+// stats returns how often sessions to device had to wait for a slot and how often they gave up waiting
+func (l *sessionLimiter) stats(device *connector.Device) (queued, rejected float64) {
+	d := l.limiterForDevice(device)
+
+	return float64(atomic.LoadUint64(&d.queued)), float64(atomic.LoadUint64(&d.rejected))
+}
+
+func (l *sessionLimiter) limiterForDevice(device *connector.Device) *deviceLimiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	d, found := l.devices[device.Address()]
+	if !found {
+		d = &deviceLimiter{}
+		l.devices[device.Address()] = d
+	}
+
+	return d
+}
+
+// take occupies a global slot and one of device at once, waiting until both are free
+func (l *sessionLimiter) take(ctx context.Context, d *deviceLimiter, device *connector.Device, queued *bool) error {
+	for {
+		l.mu.Lock()
+		if l.fits(d, device) {
+			l.active++
+			d.active++
+			l.mu.Unlock()
+			return nil
+		}
+		changed := l.changed
+		l.mu.Unlock()
+
+		d.markQueued(queued)
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			atomic.AddUint64(&d.rejected, 1)
+			return ctx.Err()
+		}
+	}
+}
+
+// fits returns whether one more session to device is within the limits, l.mu must be held
+func (l *sessionLimiter) fits(d *deviceLimiter, device *connector.Device) bool {
+	if l.cfg.MaxConcurrency > 0 && l.active >= l.cfg.MaxConcurrency {
+		return false
+	}
+
+	maxSessions := l.cfg.MaxSessions
+	if device.DeviceConfig.MaxSessions != nil {
+		maxSessions = *device.DeviceConfig.MaxSessions
+	}
+
+	return maxSessions <= 0 || d.active < maxSessions
+}
+
+func (l *sessionLimiter) release(d *deviceLimiter) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.active--
+	d.active--
+	l.notify()
+}
+
+// notify wakes up the sessions waiting for a slot, l.mu must be held
+func (l *sessionLimiter) notify() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+func (l *sessionLimiter) minLoginInterval(device *connector.Device) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	interval := l.cfg.MinLoginInterval
+	if device.DeviceConfig.MinLoginInterval != nil {
+		interval = *device.DeviceConfig.MinLoginInterval
+	}
+
+	return time.Duration(interval) * time.Second
+}
+
+// awaitLogin delays a login until interval has passed since the previous login to the device
+func (d *deviceLimiter) awaitLogin(ctx context.Context, interval time.Duration, queued *bool) error {
+	d.loginMu.Lock()
+	defer d.loginMu.Unlock()
+
+	wait := time.Until(d.lastLogin.Add(interval))
+	if wait > 0 {
+		d.markQueued(queued)
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			atomic.AddUint64(&d.rejected, 1)
+			return ctx.Err()
+		}
+	}
+
+	d.lastLogin = time.Now()
+	return nil
+}
+
+// markQueued counts the session as queued unless it already was
+func (d *deviceLimiter) markQueued(queued *bool) {
+	if *queued {
+		return
+	}
+
+	*queued = true
+	atomic.AddUint64(&d.queued, 1)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shenjler/ssh_ping_exporter/config"
+	"github.com/shenjler/ssh_ping_exporter/connector"
+)
+
+func limitedConfig(maxConcurrency, maxSessions int) *config.Config {
+	c := config.New()
+	c.MaxConcurrency = maxConcurrency
+	c.MaxSessions = maxSessions
+
+	return c
+}
+
+// acquireWithin tries to acquire a session slot for device within timeout
+func acquireWithin(l *sessionLimiter, device *connector.Device, timeout time.Duration) (func(), error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	release, _, err := l.acquire(ctx, device)
+	return release, err
+}
+
+func TestSessionLimiterReloadWhileSessionsHeld(t *testing.T) {
+	tests := []struct {
+		name           string
+		maxConcurrency int
+		maxSessions    int
+		second         *connector.Device
+	}{
+		{
+			name:        "max sessions",
+			maxSessions: 1,
+			second:      &connector.Device{Host: "lab-sw1", Port: "22", DeviceConfig: &config.DeviceConfig{}},
+		},
+		{
+			name:           "max concurrency",
+			maxConcurrency: 1,
+			second:         &connector.Device{Host: "lab-sw2", Port: "22", DeviceConfig: &config.DeviceConfig{}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := newSessionLimiter()
+			l.configure(limitedConfig(test.maxConcurrency, test.maxSessions))
+
+			first := &connector.Device{Host: "lab-sw1", Port: "22", DeviceConfig: &config.DeviceConfig{}}
+			release, err := acquireWithin(l, first, time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// a reload must not hand out the slot held by the session started before
+			l.configure(limitedConfig(test.maxConcurrency, test.maxSessions))
+			_, err = acquireWithin(l, test.second, 50*time.Millisecond)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("got %v after reload, expected to wait for the held slot", err)
+			}
+
+			done := make(chan error)
+			go func() {
+				r, err := acquireWithin(l, test.second, time.Second)
+				if err == nil {
+					r()
+				}
+				done <- err
+			}()
+			release()
+			if err := <-done; err != nil {
+				t.Errorf("got %v, expected the released slot to be taken", err)
+			}
+		})
+	}
+}
+
+func TestSessionLimiterReloadRaisesLimit(t *testing.T) {
+	l := newSessionLimiter()
+	l.configure(limitedConfig(1, 0))
+
+	device := &connector.Device{Host: "lab-sw1", Port: "22", DeviceConfig: &config.DeviceConfig{}}
+	release, err := acquireWithin(l, device, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	done := make(chan error)
+	go func() {
+		r, err := acquireWithin(l, device, time.Second)
+		if err == nil {
+			r()
+		}
+		done <- err
+	}()
+
+	l.configure(limitedConfig(2, 0))
+	if err := <-done; err != nil {
+		t.Errorf("got %v, expected a waiting session to get a slot added by the reload", err)
+	}
+}
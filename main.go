@@ -2,47 +2,74 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
 	"os/signal"
+	"regexp"
+	"strconv"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/common/log"
+	"github.com/shenjler/ssh_ping_exporter/audit"
+	"github.com/shenjler/ssh_ping_exporter/collector"
 	"github.com/shenjler/ssh_ping_exporter/config"
 	"github.com/shenjler/ssh_ping_exporter/connector"
+	"github.com/shenjler/ssh_ping_exporter/driver"
+	"github.com/shenjler/ssh_ping_exporter/rpc"
 )
 
 const version string = "0.2"
 
 var (
-	showVersion        = flag.Bool("version", false, "Print version information.")
-	listenAddress      = flag.String("web.listen-address", ":9362", "Address on which to expose metrics and web interface.")
-	metricsPath        = flag.String("web.telemetry-path", "/metrics", "Path under which to expose metrics.")
-	sshHosts           = flag.String("ssh.targets", "", "SSH Hosts to scrape")
-	sshUsername        = flag.String("ssh.user", "cisco_exporter", "Username to use for SSH connection")
-	sshPassword        = flag.String("ssh.password", "", "Password to use for SSH connection")
-	sshKeyFile         = flag.String("ssh.keyfile", "", "Key file to use for SSH connection")
-	sshTimeout         = flag.Int("ssh.timeout", 5, "Timeout to use for SSH connection")
-	sshBatchSize       = flag.Int("ssh.batch-size", 10000, "The SSH response batch size")
-	debug              = flag.Bool("debug", false, "Show verbose debug output in log")
-	legacyCiphers      = flag.Bool("legacy.ciphers", false, "Allow legacy CBC ciphers")
-	bgpEnabled         = flag.Bool("bgp.enabled", true, "Scrape bgp metrics")
-	environmentEnabled = flag.Bool("environment.enabled", true, "Scrape environment metrics")
-	factsEnabled       = flag.Bool("facts.enabled", true, "Scrape system metrics")
-	interfacesEnabled  = flag.Bool("interfaces.enabled", true, "Scrape interface metrics")
-	opticsEnabled      = flag.Bool("optics.enabled", true, "Scrape optic metrics")
-	configFile         = flag.String("config.file", "", "Path to config file")
-	devices            []*connector.Device
-	cfg                *config.Config
-	reloadCh           chan chan error
-	configMu           sync.RWMutex
-	dest               = flag.String("ssh.ping-dest", "baidu.com", "The target ip or domain to Ping")
+	showVersion         = flag.Bool("version", false, "Print version information.")
+	listenAddress       = flag.String("web.listen-address", ":9362", "Address on which to expose metrics and web interface.")
+	metricsPath         = flag.String("web.telemetry-path", "/metrics", "Path under which to expose metrics.")
+	sshHosts            = flag.String("ssh.targets", "", "SSH Hosts to scrape")
+	sshUsername         = flag.String("ssh.user", "cisco_exporter", "Username to use for SSH connection")
+	sshPassword         = flag.String("ssh.password", "", "Password to use for SSH connection")
+	sshKeyFile          = flag.String("ssh.keyfile", "", "Key file to use for SSH connection")
+	sshProxy            = flag.String("ssh.proxy", "", "Proxy to connect through (socks5://[user:password@]host:port or http://[user:password@]host:port)")
+	sshPTY              = flag.String("ssh.pty", "auto", "PTY mode of the shell session: auto (fall back to no PTY if refused), required, none")
+	sshTimeout          = flag.Int("ssh.timeout", 5, "Timeout to use for SSH connection")
+	scrapeTimeout       = flag.Int("scrape.timeout", 0, "Timeout in seconds for a whole scrape if Prometheus does not send one (0 = no limit)")
+	timeoutOffset       = flag.Float64("scrape.timeout-offset", 0.5, "Offset in seconds to subtract from the timeout sent by Prometheus")
+	sshBatchSize        = flag.Int("ssh.batch-size", 10000, "The SSH response batch size")
+	sshMaxOutput        = flag.Int("ssh.max-output", 16<<20, "Maximum output in bytes accepted for a single command")
+	sshMaxConcurrency   = flag.Int("ssh.max-concurrency", 0, "Maximum number of concurrent SSH sessions over all devices (0 = unlimited)")
+	sshMaxSessions      = flag.Int("ssh.max-sessions", 0, "Maximum number of concurrent SSH sessions per device (0 = unlimited)")
+	sshLoginInterval    = flag.Int("ssh.min-login-interval", 0, "Minimum time in seconds between two logins to the same device")
+	sshRetries          = flag.Int("ssh.retries", 0, "Number of times a failed SSH connection is retried")
+	sshRetryBackoff     = flag.Int("ssh.retry-backoff", 500, "Base backoff in milliseconds between two SSH connection attempts")
+	breakerThreshold    = flag.Int("ssh.circuit-breaker-threshold", 0, "Consecutive connection failures after which a device is not dialed for a while (0 = disabled)")
+	breakerCooldown     = flag.Int("ssh.circuit-breaker-cooldown", 60, "Time in seconds a device is not dialed after the circuit breaker opened")
+	keepaliveInterval   = flag.Int("ssh.keepalive-interval", 0, "Interval in seconds between two SSH keepalives (0 = disabled)")
+	identifyCacheTTL    = flag.Int("ssh.identify-cache-ttl", 3600, "Time in seconds the identified OS of a device is cached (0 = identify on every scrape)")
+	keepaliveMaxMissed  = flag.Int("ssh.keepalive-max-missed", 3, "Unanswered keepalives in a row after which a session is considered dead")
+	debug               = flag.Bool("debug", false, "Show verbose debug output in log")
+	legacyCiphers       = flag.Bool("legacy.ciphers", false, "Allow legacy CBC ciphers")
+	algorithmPreset     = flag.String("ssh.algorithms", "", "Preset of SSH algorithms to offer (modern, legacy)")
+	collectorFlags      = collectorFlagsFromRegistry()
+	configFile          = flag.String("config.file", "", "Path to config file")
+	transcriptMode      = flag.String("transcript.mode", "", "Record the commands sent to devices (record) or serve recorded transcripts instead of connecting to devices (replay)")
+	transcriptDir       = flag.String("transcript.dir", ".", "Directory of the transcript files")
+	transcriptTiming    = flag.Bool("transcript.timing", false, "Replay commands with the duration they had when recorded")
+	devices             []*connector.Device
+	cfg                 *config.Config
+	availableCollectors []*availableCollector
+	limiter             = newSessionLimiter()
+	statuses            = newStatusTracker()
+	reloadCh            chan chan error
+	configMu            sync.RWMutex
+	dest                = flag.String("ssh.ping-dest", "", "The target ip or domain to Ping if the scrape names none (default: dest of the icmp collector config)")
+	destRegexp          = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.:-]*$`)
 )
 
 func init() {
@@ -81,12 +108,12 @@ func initChannels() {
 			select {
 			case <-hup:
 				log.Infoln("Reload signal received as SIGHUP")
-				if err := reinitialize(); err != nil {
+				if err := initialize(); err != nil {
 					log.Errorf("Error reloading config: %s", err)
 				}
 			case rc := <-reloadCh:
 				log.Infoln("Reload signal received via POST")
-				if err := reinitialize(); err != nil {
+				if err := initialize(); err != nil {
 					log.Errorf("Error reloading config: %s", err)
 					rc <- err
 				} else {
@@ -97,13 +124,6 @@ func initChannels() {
 	}()
 }
 
-func reinitialize() error {
-	configMu.Lock()
-	defer configMu.Unlock()
-
-	return initialize()
-}
-
 func loadConfig() (*config.Config, error) {
 	if len(*configFile) == 0 {
 		log.Infoln("Loading config flags")
@@ -125,39 +145,133 @@ func initialize() error {
 		return err
 	}
 
-	devices, err = devicesForConfig(c)
+	st, err := buildState(c)
 	if err != nil {
 		return err
 	}
-	cfg = c
+	st.apply()
 
 	return nil
 }
 
+// exporterState is everything derived from a config. It is built completely before any of it takes effect,
+// an invalid config leaves the exporter as it was.
+type exporterState struct {
+	cfg        *config.Config
+	devices    []*connector.Device
+	collectors []*availableCollector
+	templates  *driver.Templates
+	allowlists *rpc.Allowlists
+	auditLog   *audit.Log
+}
+
+func buildState(c *config.Config) (*exporterState, error) {
+	devs, err := devicesForConfig(c)
+	if err != nil {
+		return nil, err
+	}
+
+	cols, err := collectorsForConfig(c)
+	if err != nil {
+		return nil, err
+	}
+
+	templates, err := driver.LoadTemplates(c)
+	if err != nil {
+		return nil, err
+	}
+
+	allowlists, err := rpc.CompileAllowlists(c)
+	if err != nil {
+		return nil, err
+	}
+
+	// opened last, nothing can fail afterwards which would leave it open
+	auditLog, err := audit.Open(c.Audit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &exporterState{
+		cfg:        c,
+		devices:    devs,
+		collectors: cols,
+		templates:  templates,
+		allowlists: allowlists,
+		auditLog:   auditLog,
+	}, nil
+}
+
+// apply makes st the state of the exporter at once, scrapes see either the old or the new state
+func (st *exporterState) apply() {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	cfg = st.cfg
+	devices = st.devices
+	availableCollectors = st.collectors
+	limiter.configure(st.cfg)
+	driver.UseTemplates(st.templates)
+	rpc.UseAllowlists(st.allowlists)
+	rpc.SetIdentifyCacheTTL(time.Duration(st.cfg.IdentifyCacheTTL) * time.Second)
+	audit.Use(st.auditLog)
+	connector.PruneBreakers(st.devices)
+}
+
 func loadConfigFromFlags() *config.Config {
 	c := config.New()
 
 	c.Debug = *debug
 	c.LegacyCiphers = *legacyCiphers
+	c.Algorithms = &config.AlgorithmConfig{Preset: *algorithmPreset}
 	c.Timeout = *sshTimeout
+	c.ScrapeTimeout = *scrapeTimeout
 	c.BatchSize = *sshBatchSize
+	c.MaxOutput = *sshMaxOutput
+	c.MaxConcurrency = *sshMaxConcurrency
+	c.MaxSessions = *sshMaxSessions
+	c.MinLoginInterval = *sshLoginInterval
+	c.Retries = *sshRetries
+	c.RetryBackoff = *sshRetryBackoff
+	c.CircuitBreakerThreshold = *breakerThreshold
+	c.CircuitBreakerCooldown = *breakerCooldown
+	c.KeepaliveInterval = *keepaliveInterval
+	c.KeepaliveMaxMissed = *keepaliveMaxMissed
+	c.IdentifyCacheTTL = *identifyCacheTTL
 	c.Username = *sshUsername
 	c.Password = *sshPassword
 
 	c.KeyFile = *sshKeyFile
+	c.Proxy = *sshProxy
+	c.PTY = *sshPTY
 
 	c.DevicesFromTargets(*sshHosts)
 
-	f := c.Features
-	f.BGP = bgpEnabled
-	f.Environment = environmentEnabled
-	f.Facts = factsEnabled
-	f.Interfaces = interfacesEnabled
-	f.Optics = opticsEnabled
+	if *transcriptMode != "" {
+		c.Transcripts = &config.TranscriptConfig{
+			Mode:      *transcriptMode,
+			Directory: *transcriptDir,
+			Timing:    *transcriptTiming,
+		}
+	}
+
+	for name, enabled := range collectorFlags {
+		c.Features[name] = *enabled
+	}
 
 	return c
 }
 
+// collectorFlagsFromRegistry defines a flag -collector.<name> per registered collector
+func collectorFlagsFromRegistry() map[string]*bool {
+	flags := make(map[string]*bool)
+	for _, r := range collector.Registered() {
+		flags[r.Name] = flag.Bool("collector."+r.Name, r.DefaultEnabled, "Enable the "+r.Name+" collector: "+r.Help)
+	}
+
+	return flags
+}
+
 func printVersion() {
 	fmt.Println("ssh_ping_exporter")
 	fmt.Printf("Version: %s\n", version)
@@ -173,12 +287,14 @@ func startServer() {
 			<body>
 			<h1>Cisco Exporter</h1>
 			<p><a href="` + *metricsPath + `">Metrics</a></p>
+			<p><a href="/status">Collector status</a></p>
 			<h2>More information:</h2>
 			<p><a href="https://github.com/shenjler/ssh_ping_exporter">github.com/shenjler/ssh_ping_exporter</a></p>
 			</body>
 			</html>`))
 	})
 	http.HandleFunc(*metricsPath, handleMetricsRequest)
+	http.HandleFunc("/status", handleStatusRequest)
 	http.HandleFunc("/-/reload", updateConfiguration)
 
 	log.Infof("Listening for %s on %s\n", *metricsPath, *listenAddress)
@@ -206,11 +322,20 @@ func handleMetricsRequest(w http.ResponseWriter, r *http.Request) {
 	if pingDest == "" {
 		pingDest = *dest
 	}
+	if pingDest != "" && !destRegexp.MatchString(pingDest) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("ERROR - invalid dest: " + pingDest))
+		return
+	}
 	reg := prometheus.NewRegistry()
 
-	targets := devices
+	// a reload during the scrape does not affect it
+	configMu.RLock()
+	scrapeCfg, targets, available := cfg, devices, availableCollectors
+	configMu.RUnlock()
+
 	if target != "" {
-		targets = findDeviceConfig(cfg, target)
+		targets = findDeviceConfig(targets, target)
 		if targets == nil {
 			w.WriteHeader(http.StatusForbidden)
 			w.Write([]byte("ERROR - not found target: " + target))
@@ -218,7 +343,10 @@ func handleMetricsRequest(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	c := newCiscoCollector(targets, pingDest)
+	ctx, cancel := contextForRequest(r, scrapeCfg)
+	defer cancel()
+
+	c := newCiscoCollector(ctx, scrapeCfg, targets, available, r.URL.Query(), collector.ProbeSettings{Dest: pingDest})
 	reg.MustRegister(c)
 
 	promhttp.HandlerFor(reg, promhttp.HandlerOpts{
@@ -226,7 +354,31 @@ func handleMetricsRequest(w http.ResponseWriter, r *http.Request) {
 		ErrorHandling: promhttp.ContinueOnError}).ServeHTTP(w, r)
 }
 
-func findDeviceConfig(cfg *config.Config, host string) []*connector.Device {
+// contextForRequest creates the context bounding a scrape. It is canceled when the request is gone or the scrape timeout is reached.
+func contextForRequest(r *http.Request, cfg *config.Config) (context.Context, context.CancelFunc) {
+	timeout := scrapeTimeoutForRequest(r, cfg)
+	if timeout == 0 {
+		return context.WithCancel(r.Context())
+	}
+
+	return context.WithTimeout(r.Context(), timeout)
+}
+
+// scrapeTimeoutForRequest derives the timeout of a scrape from the header sent by Prometheus, falling back to the configured scrape timeout
+func scrapeTimeoutForRequest(r *http.Request, cfg *config.Config) time.Duration {
+	if v := r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds"); v != "" {
+		seconds, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			log.Errorf("Invalid scrape timeout %q: %s", v, err)
+		} else if seconds > *timeoutOffset {
+			return time.Duration((seconds - *timeoutOffset) * float64(time.Second))
+		}
+	}
+
+	return time.Duration(cfg.ScrapeTimeout) * time.Second
+}
+
+func findDeviceConfig(devices []*connector.Device, host string) []*connector.Device {
 	targets := make([]*connector.Device, 1)
 	for _, dc := range devices {
 		if dc.Host == host {
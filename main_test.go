@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shenjler/ssh_ping_exporter/config"
+)
+
+var update = flag.Bool("update", false, "Update the expected metrics in testdata/metrics")
+
+// useConfig loads the config yml and makes it the config of the exporter like a reload does
+func useConfig(t *testing.T, yml string) {
+	t.Helper()
+
+	c, err := config.Load(strings.NewReader(yml))
+	if err != nil {
+		t.Fatalf("could not load config: %s", err)
+	}
+
+	st, err := buildState(c)
+	if err != nil {
+		t.Fatalf("could not apply config: %s", err)
+	}
+	st.apply()
+	statuses = newStatusTracker()
+}
+
+// scrapeMetrics requests the metrics with query and returns them without the ones differing from run to run
+func scrapeMetrics(t *testing.T, query string) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	handleMetricsRequest(rec, httptest.NewRequest(http.MethodGet, "/metrics?"+query, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	return stableMetrics(rec.Body.String())
+}
+
+// stableTimestamps are the metrics in seconds printed by the device and thus the same from run to run
+var stableTimestamps = map[string]bool{
+	"pccw_last_login_timestamp_seconds": true,
+}
+
+// stableMetrics drops durations and timestamps, all of them are in seconds
+func stableMetrics(text string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		if line == "" {
+			continue
+		}
+		if name := metricName(line); strings.HasSuffix(name, "_seconds") && !stableTimestamps[name] {
+			continue
+		}
+		b.WriteString(line + "\n")
+	}
+
+	return b.String()
+}
+
+// metricName returns the name of the metric a line of the text format belongs to
+func metricName(line string) string {
+	if strings.HasPrefix(line, "# ") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			return ""
+		}
+		return fields[2]
+	}
+
+	return strings.FieldsFunc(line, func(r rune) bool {
+		return r == '{' || r == ' '
+	})[0]
+}
+
+// compareGolden compares the metrics with the expected ones in path, with -update they are written to path instead
+func compareGolden(t *testing.T, path, got string) {
+	t.Helper()
+
+	if *update {
+		err := ioutil.WriteFile(path, []byte(got), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := string(b)
+	if got == want {
+		return
+	}
+
+	t.Errorf("metrics differ from %s (run with -update after deliberate changes)", path)
+	gotLines := lineSet(got)
+	wantLines := lineSet(want)
+	for _, l := range strings.Split(want, "\n") {
+		if l != "" && !gotLines[l] {
+			t.Errorf("missing: %s", l)
+		}
+	}
+	for _, l := range strings.Split(got, "\n") {
+		if l != "" && !wantLines[l] {
+			t.Errorf("unexpected: %s", l)
+		}
+	}
+}
+
+func lineSet(text string) map[string]bool {
+	set := make(map[string]bool)
+	for _, l := range strings.Split(text, "\n") {
+		set[l] = true
+	}
+
+	return set
+}
@@ -1,6 +0,0 @@
-package optics
-
-type Optics struct {
-	RxPower float64
-	TxPower float64
-}
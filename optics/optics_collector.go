@@ -1,9 +1,9 @@
 package optics
 
 import (
-	"log"
-	"regexp"
+	"errors"
 
+	"github.com/shenjler/ssh_ping_exporter/driver"
 	"github.com/shenjler/ssh_ping_exporter/rpc"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -21,6 +21,14 @@ func init() {
 	l := []string{"target", "interface"}
 	opticsTXDesc = prometheus.NewDesc(prefix+"tx", "Transceiver Tx power", l, nil)
 	opticsRXDesc = prometheus.NewDesc(prefix+"rx", "Transceiver Rx power", l, nil)
+
+	collector.Register(&collector.Registration{
+		Name: "optics",
+		Help: "Optical signals (tx/rx)",
+		New: func(interface{}) (collector.RPCCollector, error) {
+			return NewCollector(), nil
+		},
+	})
 }
 
 type opticsCollector struct {
@@ -43,52 +51,79 @@ func (*opticsCollector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 // Collect collects metrics from Cisco
-func (c *opticsCollector) Collect(client *rpc.Client, ch chan<- prometheus.Metric, labelValues []string) error {
-	out, err := client.RunCommand("show interfaces stats | exclude disabled")
+func (c *opticsCollector) Collect(sc *collector.ScrapeContext, client *rpc.Client, ch chan<- prometheus.Metric) error {
+	cmd := client.Driver().Optics
+	if cmd == nil {
+		sc.Logger.Debugf("Optics are not supported (%s)", client.OSType)
+		return nil
+	}
+
+	if cmd.Structured != nil {
+		optics, err := cmd.Structured.Run(client.Runner(sc.Context))
+		var pe *driver.ParseError
+		if err != nil && !errors.As(err, &pe) {
+			return err
+		}
+		if err == nil {
+			for i, optic := range optics {
+				c.collectOptic(sc, ch, i, optic)
+			}
+			return nil
+		}
+		if cmd.Command == "" {
+			return err
+		}
+		sc.Logger.Debugf("Parse optics: %s", err)
+	}
+
+	out, err := client.RunCommand(sc.Context, cmd.Command)
 	if err != nil {
 		return err
 	}
-	interfaces, err := c.ParseInterfaces(client.OSType, out)
+	interfaces, err := cmd.ParseInterfaces(out)
 	if err != nil {
-		if client.Debug {
-			log.Printf("ParseInterfaces for %s: %s\n", labelValues[0], err.Error())
-		}
-		return nil
+		return &driver.ParseError{Command: cmd.Command, Err: err}
 	}
 
-	xeDev, _ := regexp.Compile(`\S(\d+)/(\d+)/(\d+)`)
-
+	// all transceivers are read in one exchange
+	var ifaces, cmds []string
 	for _, i := range interfaces {
-		switch client.OSType {
-		case rpc.IOS:
-			out, err = client.RunCommand("show interfaces " + i + " transceiver")
-		case rpc.NXOS:
-			out, err = client.RunCommand("show interface " + i + " transceiver details")
-		case rpc.IOSXE:
-			matches := xeDev.FindStringSubmatch(i)
-			if matches == nil {
-				continue
-			}
-			out, err = client.RunCommand("show hw-module subslot " + matches[1] + "/" + matches[2] + " transceiver " + matches[3] + " status")
-		}
-		if err != nil {
-			if client.Debug {
-				log.Printf("Transceiver command on %s: %s\n", labelValues[0], err.Error())
-			}
+		transceiverCmd, ok := cmd.Transceiver(i)
+		if !ok {
 			continue
 		}
-		optic, err := c.ParseTransceiver(client.OSType, out)
+		ifaces = append(ifaces, i)
+		cmds = append(cmds, transceiverCmd)
+	}
+	if len(cmds) == 0 {
+		return nil
+	}
+
+	outputs, err := client.RunCommands(sc.Context, cmds)
+	if err != nil {
+		return err
+	}
+
+	// the other transceivers are still reported if one can not be parsed
+	var parseErr error
+	for j, out := range outputs {
+		optic, err := cmd.ParseTransceiver(out)
 		if err != nil {
-			if client.Debug {
-				log.Printf("Transceiver data for %s: %s\n", labelValues[0], err.Error())
+			sc.Logger.Debugf("Transceiver data: %s", err)
+			if parseErr == nil {
+				parseErr = &driver.ParseError{Command: cmds[j], Err: err}
 			}
 			continue
 		}
-		l := append(labelValues, i)
-
-		ch <- prometheus.MustNewConstMetric(opticsTXDesc, prometheus.GaugeValue, float64(optic.TxPower), l...)
-		ch <- prometheus.MustNewConstMetric(opticsRXDesc, prometheus.GaugeValue, float64(optic.RxPower), l...)
+		c.collectOptic(sc, ch, ifaces[j], optic)
 	}
 
-	return nil
+	return parseErr
+}
+
+func (c *opticsCollector) collectOptic(sc *collector.ScrapeContext, ch chan<- prometheus.Metric, iface string, optic driver.Optics) {
+	l := sc.LabelValues(iface)
+
+	ch <- prometheus.MustNewConstMetric(opticsTXDesc, prometheus.GaugeValue, float64(optic.TxPower), l...)
+	ch <- prometheus.MustNewConstMetric(opticsRXDesc, prometheus.GaugeValue, float64(optic.RxPower), l...)
 }
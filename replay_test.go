@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// replayConfig replays the transcripts recorded from host with all built-in collectors enabled
+const replayConfig = `
+username: exporter
+Password: secret
+transcripts:
+  mode: replay
+  directory: testdata/transcripts
+features:
+  bgp: true
+  environment: true
+  facts: true
+  icmp: true
+  interfaces: true
+  optics: true
+devices:
+  - host: %s
+`
+
+func TestReplay(t *testing.T) {
+	for _, host := range []string{"lab-rtr1", "lab-sw1", "lab-host1"} {
+		t.Run(host, func(t *testing.T) {
+			useConfig(t, fmt.Sprintf(replayConfig, host))
+
+			got := scrapeMetrics(t, "target="+host+"&dest=192.0.2.1")
+			compareGolden(t, filepath.Join("testdata", "metrics", host+".prom"), got)
+		})
+	}
+}
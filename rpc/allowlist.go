@@ -0,0 +1,104 @@
+package rpc
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/shenjler/ssh_ping_exporter/config"
+	"github.com/shenjler/ssh_ping_exporter/driver"
+)
+
+// DefaultOS is the allowlist key used for devices whose OS is not identified (yet)
+const DefaultOS = "default"
+
+// ErrCommandNotAllowed is returned for commands not matching the allowlist of the OS
+var ErrCommandNotAllowed = errors.New("command not allowed")
+
+// builtinAllowlists are the read-only commands allowed per OS if none are configured, the default allows the commands of all drivers
+func builtinAllowlists() map[string][]string {
+	lists := make(map[string][]string)
+	for _, d := range driver.All() {
+		lists[strings.ToLower(d.OS)] = d.Allowlist
+		lists[DefaultOS] = append(lists[DefaultOS], d.Allowlist...)
+	}
+
+	return lists
+}
+
+var (
+	allowlistMu sync.RWMutex
+	allowlists  map[string][]*regexp.Regexp
+)
+
+func init() {
+	a, err := CompileAllowlists(config.New())
+	if err != nil {
+		panic(err)
+	}
+	UseAllowlists(a)
+}
+
+// Allowlists are the compiled allowlists per OS, they take effect with UseAllowlists
+type Allowlists struct {
+	lists map[string][]*regexp.Regexp
+}
+
+// CompileAllowlists compiles the built-in allowlists and the ones configured in cfg. A configured list replaces the built-in one of its OS.
+// Patterns have to match the whole command.
+func CompileAllowlists(cfg *config.Config) (*Allowlists, error) {
+	lists := make(map[string][]string)
+	for os, patterns := range builtinAllowlists() {
+		lists[os] = patterns
+	}
+	for os, patterns := range cfg.CommandAllowlist {
+		lists[strings.ToLower(os)] = patterns
+	}
+
+	compiled := make(map[string][]*regexp.Regexp)
+	for os, patterns := range lists {
+		for _, p := range patterns {
+			r, err := regexp.Compile(`^(?:` + p + `)$`)
+			if err != nil {
+				return nil, fmt.Errorf("invalid allowlist pattern %q for %s: %w", p, os, err)
+			}
+			compiled[os] = append(compiled[os], r)
+		}
+	}
+
+	return &Allowlists{lists: compiled}, nil
+}
+
+// UseAllowlists makes commands checked against a instead of the allowlists used before
+func UseAllowlists(a *Allowlists) {
+	allowlistMu.Lock()
+	allowlists = a.lists
+	allowlistMu.Unlock()
+}
+
+// checkCommand returns an error unless cmd is a single line matching the allowlist of os
+func checkCommand(os, cmd string) error {
+	for _, r := range cmd {
+		if r < ' ' || r == 0x7f {
+			return fmt.Errorf("%w: %q contains control characters", ErrCommandNotAllowed, cmd)
+		}
+	}
+
+	allowlistMu.RLock()
+	defer allowlistMu.RUnlock()
+
+	list, found := allowlists[strings.ToLower(os)]
+	if !found {
+		list = allowlists[DefaultOS]
+	}
+
+	for _, r := range list {
+		if r.MatchString(cmd) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %q", ErrCommandNotAllowed, cmd)
+}
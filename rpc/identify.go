@@ -0,0 +1,221 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shenjler/ssh_ping_exporter/driver"
+)
+
+// DeviceInfo is what identification found out about a device
+type DeviceInfo struct {
+	OSType   string
+	Version  string
+	Model    string
+	Hostname string
+}
+
+// probe is a command whose output identifies some OSes. Setup commands disable the pager, they fail harmlessly on other OSes.
+type probe struct {
+	setup    string
+	command  string
+	identify func(output string) string
+}
+
+// probes are run in order until one identifies the OS. Other OSes answer with "unknown command" errors which are ignored.
+var probes = []probe{
+	{setup: "terminal length 0", command: "show version", identify: identifyShowVersion},
+	{setup: "screen-length 0 temporary", command: "display version", identify: identifyDisplayVersion},
+	{command: "uname -a", identify: identifyUname},
+	{command: "/system resource print", identify: identifyRouterOS},
+}
+
+type cacheEntry struct {
+	info    DeviceInfo
+	expires time.Time
+}
+
+// identityCache holds identifications per device. It outlives config reloads.
+var identityCache = struct {
+	sync.Mutex
+	ttl     time.Duration
+	entries map[string]cacheEntry
+}{entries: make(map[string]cacheEntry)}
+
+// SetIdentifyCacheTTL sets how long identifications are cached (0 = not cached)
+func SetIdentifyCacheTTL(ttl time.Duration) {
+	identityCache.Lock()
+	defer identityCache.Unlock()
+
+	identityCache.ttl = ttl
+}
+
+// Identify tries to identify the OS running on a device. The result is cached per device.
+func (c *Client) Identify(ctx context.Context) error {
+	if info, found := cachedInfo(c.conn.String()); found {
+		if c.Debug {
+			log.Printf("Host %s identified as: %s (cached)\n", c.conn, info.OSType)
+		}
+		return c.useInfo(ctx, info, "")
+	}
+
+	var info DeviceInfo
+	var setup string
+	for _, p := range probes {
+		if p.setup != "" {
+			_, err := c.RunCommand(ctx, p.setup)
+			if err != nil {
+				return err
+			}
+		}
+
+		output, err := c.RunCommand(ctx, p.command)
+		if err != nil {
+			return err
+		}
+
+		if os := p.identify(output); os != "" {
+			info = parseInfo(os, output)
+			setup = p.setup
+			break
+		}
+	}
+
+	if info.OSType == "" {
+		return errors.New("Unknown OS")
+	}
+
+	cacheInfo(c.conn.String(), info)
+	if c.Debug {
+		log.Printf("Host %s identified as: %s\n", c.conn, info.OSType)
+	}
+
+	return c.useInfo(ctx, info, setup)
+}
+
+// SetOS skips identification and treats the device as running os
+func (c *Client) SetOS(ctx context.Context, os string) error {
+	if !driver.Known(os) {
+		return fmt.Errorf("unknown OS %q", os)
+	}
+
+	return c.useInfo(ctx, DeviceInfo{OSType: strings.ToUpper(os)}, "")
+}
+
+// useInfo switches the session to the OS in info, disables the pager unless the command already ran and escalates privileges if requested
+func (c *Client) useInfo(ctx context.Context, info DeviceInfo, ranSetup string) error {
+	c.Info = info
+	c.OSType = info.OSType
+	c.conn.SetOS(c.OSType)
+
+	d := c.Driver()
+	if cmd := d.PagerDisable; cmd != "" && cmd != ranSetup {
+		_, err := c.RunCommand(ctx, cmd)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.Privileged && d.Privilege != "" {
+		_, err := c.RunCommand(ctx, d.Privilege)
+		return err
+	}
+
+	return nil
+}
+
+func parseInfo(os, output string) DeviceInfo {
+	info := DeviceInfo{OSType: os}
+
+	r := driver.ForOS(os).Info
+	info.Version = firstSubmatch(r.Version, output)
+	info.Model = firstSubmatch(r.Model, output)
+	info.Hostname = firstSubmatch(r.Hostname, output)
+
+	return info
+}
+
+func firstSubmatch(r *regexp.Regexp, s string) string {
+	if r == nil {
+		return ""
+	}
+
+	if matches := r.FindStringSubmatch(s); matches != nil {
+		return matches[1]
+	}
+
+	return ""
+}
+
+func cachedInfo(device string) (DeviceInfo, bool) {
+	identityCache.Lock()
+	defer identityCache.Unlock()
+
+	e, found := identityCache.entries[device]
+	if !found || time.Now().After(e.expires) {
+		return DeviceInfo{}, false
+	}
+
+	return e.info, true
+}
+
+func cacheInfo(device string, info DeviceInfo) {
+	identityCache.Lock()
+	defer identityCache.Unlock()
+
+	if identityCache.ttl <= 0 {
+		return
+	}
+
+	identityCache.entries[device] = cacheEntry{info: info, expires: time.Now().Add(identityCache.ttl)}
+}
+
+func identifyShowVersion(output string) string {
+	switch {
+	case strings.Contains(output, "IOS XE") || strings.Contains(output, "IOS-XE"):
+		return driver.IOSXE
+	case strings.Contains(output, "NX-OS") || strings.Contains(output, "Nexus Operating System"):
+		return driver.NXOS
+	case strings.Contains(output, "Arista"):
+		return driver.EOS
+	case strings.Contains(output, "JUNOS") || strings.Contains(output, "Junos:"):
+		return driver.JUNOS
+	case strings.Contains(output, "IOS Software") || strings.Contains(output, "Internetwork Operating System"):
+		return driver.IOS
+	}
+
+	return ""
+}
+
+func identifyDisplayVersion(output string) string {
+	switch {
+	case strings.Contains(output, "Huawei Versatile Routing Platform") || strings.Contains(output, "VRP (R) software"):
+		return driver.VRP
+	case strings.Contains(output, "Comware"):
+		return driver.COMWARE
+	}
+
+	return ""
+}
+
+func identifyUname(output string) string {
+	if strings.HasPrefix(strings.TrimSpace(output), "Linux ") {
+		return driver.LINUX
+	}
+
+	return ""
+}
+
+func identifyRouterOS(output string) string {
+	if strings.Contains(output, "RouterOS") || strings.Contains(output, "platform: MikroTik") {
+		return driver.ROUTEROS
+	}
+
+	return ""
+}
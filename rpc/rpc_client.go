@@ -1,69 +1,155 @@
 package rpc
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"log"
 
+	"github.com/shenjler/ssh_ping_exporter/audit"
 	"github.com/shenjler/ssh_ping_exporter/connector"
-)
-
-const (
-	IOSXE string = "IOSXE"
-	NXOS  string = "NXOS"
-	IOS   string = "IOS"
+	"github.com/shenjler/ssh_ping_exporter/driver"
 )
 
 // Client sends commands to a Cisco device
 type Client struct {
-	conn   *connector.SSHConnection
-	Debug  bool
-	OSType string
+	conn     connector.Transport
+	username string
+	Debug    bool
+	OSType   string
+	Info     DeviceInfo
+	// Privileged escalates to privileged mode after the OS is known, if the driver supports it
+	Privileged bool
 }
 
-// NewClient creates a new client connection
-func NewClient(ssh *connector.SSHConnection, debug bool) *Client {
-	rpc := &Client{conn: ssh, Debug: debug}
+// NewClient creates a new client connection, username is recorded in the audit log
+func NewClient(conn connector.Transport, username string, debug bool) *Client {
+	rpc := &Client{conn: conn, username: username, Debug: debug}
 
 	return rpc
 }
 
-// Identify tries to identify the OS running on a Cisco device
-func (c *Client) Identify() error {
-	output, err := c.RunCommand("show version")
-	if err != nil {
-		return err
+// RunCommand runs a command on a Cisco device. Commands not matching the allowlist of the OS are rejected.
+// Every command is recorded in the audit log.
+func (c *Client) RunCommand(ctx context.Context, cmd string) (string, error) {
+	if c.Debug {
+		log.Printf("Running command on %s: %s\n", c.conn, cmd)
 	}
-	switch {
-	case strings.Contains(output, "IOS XE"):
-		c.OSType = IOSXE
-	case strings.Contains(output, "NX-OS"):
-		c.OSType = NXOS
-	case strings.Contains(output, "IOS Software"):
-		c.OSType = IOS
-	default:
-		return errors.New("Unknown OS")
+	t := time.Now()
+	if err := checkCommand(c.osKey(), cmd); err != nil {
+		c.audit(cmd, t, "", err, 0)
+		return "", err
+	}
+
+	output, err := c.conn.RunCommand(ctx, fmt.Sprintf("%s", cmd))
+	c.audit(cmd, t, output, err, 0)
+	if err != nil {
+		if c.Debug {
+			log.Printf("Command %q on %s failed: %s\n", cmd, c.conn, err)
+		}
+		return "", err
 	}
 	if c.Debug {
-		log.Printf("Host %s identified as: %s\n", c.conn.Host, c.OSType)
+		log.Printf("Output of %q on %s: %s\n", cmd, c.conn, output)
 	}
-	return nil
+
+	return output, nil
 }
 
-// RunCommand runs a command on a Cisco device
-func (c *Client) RunCommand(cmd string) (string, error) {
+// RunCommands runs cmds back-to-back in one exchange if the transport supports it and returns the output of each command.
+// Nothing is sent if one of the commands does not match the allowlist. Every command is recorded in the audit log.
+func (c *Client) RunCommands(ctx context.Context, cmds []string) ([]string, error) {
 	if c.Debug {
-		log.Printf("Running command on %s: %s\n", c.conn.Host, cmd)
+		log.Printf("Running %d commands on %s: %s\n", len(cmds), c.conn, strings.Join(cmds, "; "))
+	}
+	t := time.Now()
+	for _, cmd := range cmds {
+		if err := checkCommand(c.osKey(), cmd); err != nil {
+			c.audit(cmd, t, "", err, 0)
+			return nil, err
+		}
+	}
+
+	bt, ok := c.conn.(connector.BatchTransport)
+	if !ok {
+		result := make([]string, len(cmds))
+		for i, cmd := range cmds {
+			out, err := c.RunCommand(ctx, cmd)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = out
+		}
+		return result, nil
 	}
-	output, err := c.conn.RunCommand(fmt.Sprintf("%s", cmd))
-	log.Printf("output: %s\n", output)
 
+	outputs, err := bt.RunCommands(ctx, cmds)
+	for i, cmd := range cmds {
+		output := ""
+		if err == nil {
+			output = outputs[i]
+		}
+		// the outputs arrive together, every command gets the duration of the whole batch
+		c.audit(cmd, t, output, err, len(cmds))
+	}
 	if err != nil {
-		println(err.Error())
-		return "", err
+		return nil, err
 	}
 
-	return output, nil
+	return outputs, nil
+}
+
+// Driver returns the driver of the OS of the device, the generic one until the OS is known
+func (c *Client) Driver() *driver.Driver {
+	return driver.ForOS(c.OSType)
+}
+
+// Runner returns a driver.Runner running commands with RunCommand
+func (c *Client) Runner(ctx context.Context) driver.Runner {
+	return func(cmd string) (string, error) {
+		return c.RunCommand(ctx, cmd)
+	}
+}
+
+// BatchRunner returns a driver.BatchRunner running commands with RunCommands
+func (c *Client) BatchRunner(ctx context.Context) driver.BatchRunner {
+	return func(cmds []string) ([]string, error) {
+		return c.RunCommands(ctx, cmds)
+	}
+}
+
+// osKey returns the OS used to select allowlist and sanitizer rules
+func (c *Client) osKey() string {
+	if c.OSType == "" {
+		return DefaultOS
+	}
+
+	return c.OSType
+}
+
+// audit records cmd in the audit log, batchSize is the number of commands sent together with cmd or 0 if it was sent alone
+func (c *Client) audit(cmd string, started time.Time, output string, err error, batchSize int) {
+	e := audit.Entry{
+		Timestamp:   started,
+		Device:      c.conn.String(),
+		Username:    c.username,
+		Command:     cmd,
+		Duration:    time.Since(started).Seconds(),
+		OutputBytes: len(output),
+		Result:      audit.ResultSuccess,
+		Output:      output,
+		BatchSize:   batchSize,
+	}
+	if errors.Is(err, ErrCommandNotAllowed) {
+		e.Result = audit.ResultDenied
+		e.Error = err.Error()
+	} else if err != nil {
+		e.Result = audit.ResultError
+		e.Error = err.Error()
+	}
+
+	audit.Record(e)
 }
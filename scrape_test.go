@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/shenjler/ssh_ping_exporter/fakedevice"
+)
+
+// liveConfig scrapes the device at %s with all built-in collectors enabled
+const liveConfig = `
+username: exporter
+Password: secret
+timeout: 2
+features:
+  bgp: true
+  environment: true
+  facts: true
+  icmp: true
+  interfaces: true
+  optics: true
+devices:
+  - host: %s
+`
+
+// startFakeDevice starts a fake device for the duration of the test and returns its address
+func startFakeDevice(t *testing.T, dev fakedevice.Device) string {
+	t.Helper()
+
+	s, err := fakedevice.Start("127.0.0.1:0", dev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	return s.Addr()
+}
+
+func TestScrapeFakeDevices(t *testing.T) {
+	tests := []struct {
+		name     string
+		device   fakedevice.Device
+		expected []string
+	}{
+		{
+			name:   "linux",
+			device: fakedevice.Linux("host1"),
+			expected: []string{
+				`pccw_up{reason="",target="127.0.0.1"} 1`,
+				`pccw_device_os_info{hostname="host1",model="",os="LINUX",target="127.0.0.1",version="5.4.0-150-generic"} 1`,
+				`pccw_last_login_timestamp_seconds{source="10.0.0.10",target="127.0.0.1"} 1.791796442e+09`,
+				`pccw_icmp_packet_loss{dest="192.0.2.1",src="127.0.0.1"} 0`,
+				`pccw_icmp_rtt_ms{dest="192.0.2.1",src="127.0.0.1"} 32.6`,
+				`pccw_collector_success{collector="icmp",target="127.0.0.1"} 1`,
+			},
+		},
+		{
+			name:   "cisco",
+			device: fakedevice.Cisco("rtr1"),
+			expected: []string{
+				`pccw_up{reason="",target="127.0.0.1"} 1`,
+				`pccw_device_os_info{hostname="rtr1",model="ASR1001-X",os="IOSXE",target="127.0.0.1",version="16.09.04"} 1`,
+				`cisco_bgp_session_up{asn="65001",ip="10.0.0.2",target="127.0.0.1"} 1`,
+				`cisco_bgp_session_prefixes_received_count{asn="65001",ip="10.0.0.2",target="127.0.0.1"} 12`,
+				`cisco_environment_sensor_temp{item="R0 Inlet",target="127.0.0.1"} 31`,
+				`cisco_interface_receive_bytes{description="uplink core1",mac="00a3.d1f4.2a00",name="GigabitEthernet0/0/0",speed="",target="127.0.0.1"} 9.87654321e+08`,
+				`pccw_icmp_status{dest="192.0.2.1",src="127.0.0.1"} 1`,
+				`pccw_collector_success{collector="interfaces",target="127.0.0.1"} 1`,
+			},
+		},
+		{
+			name:   "huawei",
+			device: fakedevice.Huawei("sw1"),
+			expected: []string{
+				`pccw_up{reason="",target="127.0.0.1"} 1`,
+				`pccw_device_os_info{hostname="sw1",model="CE6850-48S6Q-HI",os="VRP",target="127.0.0.1",version="8.180 (CE6850 V200R005C10SPC800)"} 1`,
+				`pccw_last_login_timestamp_seconds{source="10.0.0.10",target="127.0.0.1"} 1.792200612e+09`,
+				`cisco_bgp_session_up{asn="65001",ip="10.0.0.1",target="127.0.0.1"} 1`,
+				`pccw_icmp_packet_loss{dest="192.0.2.1",src="127.0.0.1"} 0`,
+				`pccw_collector_success{collector="bgp",target="127.0.0.1"} 1`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useConfig(t, fmt.Sprintf(liveConfig, startFakeDevice(t, test.device)))
+
+			got := scrapeMetrics(t, "dest=192.0.2.1")
+			for _, line := range test.expected {
+				if !strings.Contains(got, line+"\n") {
+					t.Errorf("missing: %s", line)
+				}
+			}
+			if t.Failed() {
+				t.Logf("metrics:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestScrapeLoginBanner(t *testing.T) {
+	useConfig(t, fmt.Sprintf(liveConfig, startFakeDevice(t, fakedevice.Cisco("rtr1"))))
+
+	got := scrapeMetrics(t, "dest=192.0.2.1")
+	if !strings.Contains(got, `pccw_login_banner_info{banner="*** Authorized access only ***",hash="`) {
+		t.Errorf("banner missing in:\n%s", got)
+	}
+}
+
+func TestScrapePrivileged(t *testing.T) {
+	dev := fakedevice.Cisco("rtr1")
+	dev.EnablePassword = "enable-secret"
+	useConfig(t, "privileged: true\nenable_password: enable-secret\n"+fmt.Sprintf(liveConfig, startFakeDevice(t, dev)))
+
+	got := scrapeMetrics(t, "dest=192.0.2.1")
+	for _, line := range []string{
+		`pccw_device_os_info{hostname="rtr1",model="ASR1001-X",os="IOSXE",target="127.0.0.1",version="16.09.04"} 1`,
+		`cisco_bgp_session_up{asn="65001",ip="10.0.0.2",target="127.0.0.1"} 1`,
+	} {
+		if !strings.Contains(got, line+"\n") {
+			t.Errorf("missing: %s\nmetrics:\n%s", line, got)
+		}
+	}
+}
+
+func TestScrapeUnreachableDevice(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	useConfig(t, fmt.Sprintf(liveConfig, addr))
+
+	got := scrapeMetrics(t, "")
+	for _, line := range []string{
+		`pccw_up{reason="tcp_refused",target="127.0.0.1"} 0`,
+		`pccw_connect_failure{reason="tcp_refused",target="127.0.0.1"} 1`,
+	} {
+		if !strings.Contains(got, line+"\n") {
+			t.Errorf("missing: %s\nmetrics:\n%s", line, got)
+		}
+	}
+}
+
+func TestScrapeInvalidRequests(t *testing.T) {
+	useConfig(t, fmt.Sprintf(liveConfig, "127.0.0.1:22"))
+
+	tests := []struct {
+		query  string
+		status int
+	}{
+		{query: "target=unknown", status: http.StatusForbidden},
+		{query: "dest=-invalid", status: http.StatusBadRequest},
+		{query: "dest=" + strings.Repeat("x", 10) + "%3Bid", status: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		handleMetricsRequest(rec, httptest.NewRequest(http.MethodGet, "/metrics?"+test.query, nil))
+		if rec.Code != test.status {
+			t.Errorf("%s: got status %d, expected %d", test.query, rec.Code, test.status)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"html/template"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/log"
+	"github.com/shenjler/ssh_ping_exporter/driver"
+)
+
+// Failure kinds of a collector
+const (
+	failureCommand = "command"
+	failureParse   = "parse"
+)
+
+// collectorStatus is the outcome of the runs of a collector for one target
+type collectorStatus struct {
+	Target          string
+	Collector       string
+	LastSuccess     time.Time
+	LastError       time.Time
+	LastErrorKind   string
+	LastErrorText   string
+	CommandFailures uint64
+	ParseFailures   uint64
+}
+
+type statusKey struct {
+	target    string
+	collector string
+}
+
+// statusTracker remembers the outcome of the collectors across scrapes
+type statusTracker struct {
+	mu       sync.Mutex
+	statuses map[statusKey]*collectorStatus
+}
+
+func newStatusTracker() *statusTracker {
+	return &statusTracker{
+		statuses: make(map[statusKey]*collectorStatus),
+	}
+}
+
+// failureKind tells parse failures from failures to run a command
+func failureKind(err error) string {
+	var pe *driver.ParseError
+	if errors.As(err, &pe) {
+		return failureParse
+	}
+
+	return failureCommand
+}
+
+// record stores the outcome of a run of collector for target and returns the updated status
+func (t *statusTracker) record(target, collector string, err error) collectorStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := statusKey{target: target, collector: collector}
+	s, found := t.statuses[key]
+	if !found {
+		s = &collectorStatus{Target: target, Collector: collector}
+		t.statuses[key] = s
+	}
+
+	now := time.Now()
+	if err == nil {
+		s.LastSuccess = now
+		return *s
+	}
+
+	s.LastError = now
+	s.LastErrorKind = failureKind(err)
+	s.LastErrorText = err.Error()
+	if s.LastErrorKind == failureParse {
+		s.ParseFailures++
+	} else {
+		s.CommandFailures++
+	}
+
+	return *s
+}
+
+// all returns the statuses ordered by target and collector
+func (t *statusTracker) all() []collectorStatus {
+	t.mu.Lock()
+	result := make([]collectorStatus, 0, len(t.statuses))
+	for _, s := range t.statuses {
+		result = append(result, *s)
+	}
+	t.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Target != result[j].Target {
+			return result[i].Target < result[j].Target
+		}
+		return result[i].Collector < result[j].Collector
+	})
+
+	return result
+}
+
+var statusTemplate = template.Must(template.New("status").Funcs(template.FuncMap{
+	"since": func(t time.Time) string {
+		if t.IsZero() {
+			return "never"
+		}
+		return t.Format(time.RFC3339) + " (" + time.Since(t).Truncate(time.Second).String() + " ago)"
+	},
+}).Parse(`<html>
+	<head><title>SSH ping Exporter - Collector status</title></head>
+	<body>
+	<h1>Collector status</h1>
+	<table border="1" cellpadding="4">
+	<tr><th>Target</th><th>Collector</th><th>Last success</th><th>Last error</th><th>Kind</th><th>Error</th><th>Command failures</th><th>Parse failures</th></tr>
+	{{range .}}<tr><td>{{.Target}}</td><td>{{.Collector}}</td><td>{{since .LastSuccess}}</td><td>{{since .LastError}}</td><td>{{.LastErrorKind}}</td><td><pre>{{.LastErrorText}}</pre></td><td>{{.CommandFailures}}</td><td>{{.ParseFailures}}</td></tr>
+	{{end}}</table>
+	</body>
+	</html>`))
+
+func handleStatusRequest(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := statusTemplate.Execute(w, statuses.all()); err != nil {
+		log.Errorf("status page: %s", err)
+	}
+}
@@ -0,0 +1,211 @@
+package textfsm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Record is a row of the result. Values are strings, List values are []string.
+type Record map[string]interface{}
+
+// String returns the value name, List values are joined by commas
+func (r Record) String(name string) string {
+	switch v := r[name].(type) {
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, ",")
+	}
+
+	return ""
+}
+
+// List returns the value name as list
+func (r Record) List(name string) []string {
+	switch v := r[name].(type) {
+	case string:
+		if v == "" {
+			return nil
+		}
+		return []string{v}
+	case []string:
+		return v
+	}
+
+	return nil
+}
+
+// state of a value while parsing
+type valueState struct {
+	value    *Value
+	current  string
+	list     []string
+	filldown string
+}
+
+type fsm struct {
+	t       *Template
+	values  []*valueState
+	results [][]interface{}
+	state   string
+}
+
+// ParseText runs the template over text and returns the records
+func (t *Template) ParseText(text string) ([]Record, error) {
+	f := &fsm{t: t, state: StateStart}
+	for _, v := range t.Values {
+		f.values = append(f.values, &valueState{value: v})
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		err := f.checkLine(line)
+		if err != nil {
+			return nil, err
+		}
+		if f.state == StateEnd || f.state == StateEOF {
+			break
+		}
+	}
+
+	if _, found := t.States[StateEOF]; f.state != StateEnd && !found {
+		f.appendRecord()
+	}
+
+	records := make([]Record, len(f.results))
+	for i, row := range f.results {
+		records[i] = make(Record, len(row))
+		for j, v := range row {
+			records[i][t.Values[j].Name] = v
+		}
+	}
+
+	return records, nil
+}
+
+func (f *fsm) checkLine(line string) error {
+	for _, r := range f.t.States[f.state] {
+		m := r.regex.FindStringSubmatchIndex(line)
+		if m == nil {
+			continue
+		}
+
+		for i, name := range r.regex.SubexpNames() {
+			j, found := f.t.index[name]
+			if name == "" || !found {
+				continue
+			}
+			if m[2*i] < 0 {
+				f.assign(f.values[j], "", false)
+			} else {
+				f.assign(f.values[j], line[m[2*i]:m[2*i+1]], true)
+			}
+		}
+
+		switch r.RecordOp {
+		case recordRecord:
+			f.appendRecord()
+		case recordClear:
+			f.clearRecord()
+		case recordClearall:
+			f.clearAllRecord()
+		}
+
+		switch r.LineOp {
+		case lineError:
+			msg := strings.Trim(r.NewState, `"`)
+			if msg == "" {
+				msg = "state error"
+			}
+			return fmt.Errorf("%s, line: %q", msg, line)
+		case lineContinue:
+			continue
+		}
+
+		if r.NewState != "" {
+			f.state = r.NewState
+		}
+		return nil
+	}
+
+	return nil
+}
+
+func (f *fsm) assign(v *valueState, s string, matched bool) {
+	if v.value.HasOption(List) {
+		if matched {
+			v.list = append(v.list, s)
+		}
+		return
+	}
+
+	v.current = s
+	if v.value.HasOption(Filldown) {
+		v.filldown = s
+	}
+
+	if v.value.HasOption(Fillup) && s != "" {
+		col := f.t.index[v.value.Name]
+		for i := len(f.results) - 1; i >= 0; i-- {
+			if f.results[i][col] != "" {
+				break
+			}
+			f.results[i][col] = s
+		}
+	}
+}
+
+func (f *fsm) appendRecord() {
+	row := make([]interface{}, len(f.values))
+	empty := true
+	for i, v := range f.values {
+		if v.value.HasOption(List) {
+			list := append([]string{}, v.list...)
+			if v.value.HasOption(Required) && len(list) == 0 {
+				f.clearRecord()
+				return
+			}
+			if len(list) > 0 {
+				empty = false
+			}
+			row[i] = list
+			continue
+		}
+
+		if v.value.HasOption(Required) && v.current == "" {
+			f.clearRecord()
+			return
+		}
+		if v.current != "" {
+			empty = false
+		}
+		row[i] = v.current
+	}
+
+	if empty {
+		return
+	}
+
+	f.results = append(f.results, row)
+	f.clearRecord()
+}
+
+// clearRecord resets all values except Filldown ones
+func (f *fsm) clearRecord() {
+	for _, v := range f.values {
+		if v.value.HasOption(Filldown) {
+			v.current = v.filldown
+			continue
+		}
+		v.current = ""
+		v.list = nil
+	}
+}
+
+// clearAllRecord resets all values
+func (f *fsm) clearAllRecord() {
+	for _, v := range f.values {
+		v.current = ""
+		v.filldown = ""
+		v.list = nil
+	}
+}
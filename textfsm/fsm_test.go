@@ -0,0 +1,175 @@
+package textfsm
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The templates in testdata follow the ones of ntc-templates, the outputs are samples of the respective devices
+func TestParseTextSamples(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected []map[string]string
+	}{
+		{
+			name: "cisco_ios_show_ip_bgp_summary",
+			expected: []map[string]string{
+				{"ROUTER_ID": "10.0.0.1", "LOCAL_AS": "65000", "BGP_NEIGH": "10.0.0.2", "NEIGH_AS": "65001", "MSG_RCVD": "88015", "MSG_SENT": "88012", "UP_DOWN": "8w1d", "STATE_PFXRCD": "12"},
+				{"ROUTER_ID": "10.0.0.1", "LOCAL_AS": "65000", "BGP_NEIGH": "10.0.0.6", "NEIGH_AS": "65002", "MSG_RCVD": "0", "MSG_SENT": "0", "UP_DOWN": "never", "STATE_PFXRCD": "Idle (Admin)"},
+				{"ROUTER_ID": "10.0.0.1", "LOCAL_AS": "65000", "BGP_NEIGH": "192.168.100.254", "NEIGH_AS": "65003", "MSG_RCVD": "1204", "MSG_SENT": "1199", "UP_DOWN": "3d04h", "STATE_PFXRCD": "7"},
+			},
+		},
+		{
+			name: "cisco_ios_show_vlan",
+			expected: []map[string]string{
+				{"VLAN_ID": "1", "NAME": "default", "STATUS": "active", "INTERFACES": "Gi1/0/1,Gi1/0/2,Gi1/0/3,Gi1/0/4"},
+				{"VLAN_ID": "10", "NAME": "servers", "STATUS": "active", "INTERFACES": "Gi1/0/5,Gi1/0/6"},
+				{"VLAN_ID": "20", "NAME": "voice", "STATUS": "active", "INTERFACES": ""},
+				{"VLAN_ID": "1002", "NAME": "fddi-default", "STATUS": "act/unsup", "INTERFACES": ""},
+			},
+		},
+		{
+			name: "huawei_vrp_display_interface_brief",
+			expected: []map[string]string{
+				{"INTERFACE": "10GE1/0/1", "PHY": "up", "PROTOCOL": "up", "IN_UTI": "0.01%", "OUT_UTI": "0.01%", "IN_ERRORS": "0", "OUT_ERRORS": "0"},
+				{"INTERFACE": "10GE1/0/2", "PHY": "*down", "PROTOCOL": "down", "IN_UTI": "0%", "OUT_UTI": "0%", "IN_ERRORS": "0", "OUT_ERRORS": "0"},
+				{"INTERFACE": "Eth-Trunk1", "PHY": "up", "PROTOCOL": "up", "IN_UTI": "0.03%", "OUT_UTI": "0.02%", "IN_ERRORS": "3", "OUT_ERRORS": "1"},
+				{"INTERFACE": "NULL0", "PHY": "up", "PROTOCOL": "up(s)", "IN_UTI": "0%", "OUT_UTI": "0%", "IN_ERRORS": "0", "OUT_ERRORS": "0"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tmpl, err := ParseFile(filepath.Join("testdata", test.name+".textfsm"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			raw, err := ioutil.ReadFile(filepath.Join("testdata", test.name+".raw"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			records, err := tmpl.ParseText(string(raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]map[string]string, len(records))
+			for i, r := range records {
+				got[i] = make(map[string]string)
+				for _, name := range tmpl.Header() {
+					got[i][name] = r.String(name)
+				}
+			}
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("got records:\n%v\nexpected:\n%v", got, test.expected)
+			}
+		})
+	}
+}
+
+func TestParseTextOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		text     string
+		expected []map[string]string
+		err      bool
+	}{
+		{
+			name: "required",
+			template: `Value Required NAME (\S+)
+Value STATUS (\S+)
+
+Start
+  ^name ${NAME}
+  ^status ${STATUS} -> Record
+`,
+			text:     "name a\nstatus up\nstatus down\nname b\nstatus up",
+			expected: []map[string]string{{"NAME": "a", "STATUS": "up"}, {"NAME": "b", "STATUS": "up"}},
+		},
+		{
+			name: "fillup",
+			template: `Value NAME (\S+)
+Value Fillup SLOT (\d+)
+
+Start
+  ^name ${NAME} -> Record
+  ^slot ${SLOT}
+
+EOF
+`,
+			text:     "name a\nname b\nslot 3",
+			expected: []map[string]string{{"NAME": "a", "SLOT": "3"}, {"NAME": "b", "SLOT": "3"}},
+		},
+		{
+			name: "clear",
+			template: `Value NAME (\S+)
+Value STATUS (\S+)
+
+Start
+  ^status ${STATUS}
+  ^reset -> Clear
+  ^name ${NAME} -> Record
+`,
+			text:     "status up\nreset\nname a",
+			expected: []map[string]string{{"NAME": "a", "STATUS": ""}},
+		},
+		{
+			name: "error action",
+			template: `Value NAME (\S+)
+
+Start
+  ^name ${NAME} -> Record
+  ^. -> Error "unexpected line"
+`,
+			text: "name a\n% Invalid input",
+			err:  true,
+		},
+		{
+			name: "no match",
+			template: `Value NAME (\S+)
+
+Start
+  ^name ${NAME} -> Record
+`,
+			text:     "% Invalid input",
+			expected: []map[string]string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tmpl, err := Parse(strings.NewReader(test.template))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			records, err := tmpl.ParseText(test.text)
+			if test.err {
+				if err == nil {
+					t.Errorf("expected an error, got %v", records)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]map[string]string, len(records))
+			for i, r := range records {
+				got[i] = make(map[string]string)
+				for _, name := range tmpl.Header() {
+					got[i][name] = r.String(name)
+				}
+			}
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("got records %v, expected %v", got, test.expected)
+			}
+		})
+	}
+}
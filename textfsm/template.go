@@ -0,0 +1,338 @@
+// Package textfsm implements the TextFSM template language for parsing semi-formatted CLI output,
+// compatible with the templates of ntc-templates as far as Go regular expressions allow
+package textfsm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Value options
+const (
+	Filldown = "Filldown"
+	Key      = "Key"
+	Required = "Required"
+	List     = "List"
+	Fillup   = "Fillup"
+)
+
+// Reserved states
+const (
+	StateStart = "Start"
+	StateEnd   = "End"
+	StateEOF   = "EOF"
+)
+
+// Line operations
+const (
+	lineNext     = "Next"
+	lineContinue = "Continue"
+	lineError    = "Error"
+)
+
+// Record operations
+const (
+	recordNone     = "NoRecord"
+	recordRecord   = "Record"
+	recordClear    = "Clear"
+	recordClearall = "Clearall"
+)
+
+var (
+	commentRegexp   = regexp.MustCompile(`^\s*#`)
+	stateNameRegexp = regexp.MustCompile(`^(\w+)$`)
+	ruleLineRegexp  = regexp.MustCompile(`^\s+\^`)
+	matchAction     = regexp.MustCompile(`^(.*)(\s->(.*))$`)
+	actionRegexp    = regexp.MustCompile(`^\s+(Continue|Next|Error)(?:\.(Clear|Clearall|Record|NoRecord))?(?:\s+(\w+|".*"))?$`)
+	action2Regexp   = regexp.MustCompile(`^\s+(Clear|Clearall|Record|NoRecord)(?:\s+(\w+|".*"))?$`)
+	action3Regexp   = regexp.MustCompile(`^(?:\s+(\w+|".*"))?$`)
+	varRegexp       = regexp.MustCompile(`\$\$|\$\{(\w+)\}|\$(\w+)`)
+	valueNameRegexp = regexp.MustCompile(`^\w+$`)
+)
+
+// Value is a column of the result
+type Value struct {
+	Name    string
+	Regex   string
+	Options []string
+
+	template string
+}
+
+// HasOption reports whether the value has option o
+func (v *Value) HasOption(o string) bool {
+	for _, opt := range v.Options {
+		if opt == o {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Rule is a line of a state: a regular expression and the actions taken when it matches
+type Rule struct {
+	Match    string
+	LineOp   string
+	RecordOp string
+	NewState string
+
+	regex *regexp.Regexp
+}
+
+// Template is a parsed TextFSM template
+type Template struct {
+	Values []*Value
+	States map[string][]*Rule
+
+	index map[string]int
+}
+
+// ParseFile reads the template in file
+func ParseFile(file string) (*Template, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	t, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	return t, nil
+}
+
+// Parse reads a template
+func Parse(r io.Reader) (*Template, error) {
+	t := &Template{
+		States: make(map[string][]*Rule),
+		index:  make(map[string]int),
+	}
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	next := func() (string, bool) {
+		if !scanner.Scan() {
+			return "", false
+		}
+		lineNo++
+		return strings.TrimRight(scanner.Text(), " \t\r"), true
+	}
+
+	err := t.parseValues(next, &lineNo)
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.parseStates(next, &lineNo)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return t, t.validate()
+}
+
+func (t *Template) parseValues(next func() (string, bool), lineNo *int) error {
+	for {
+		line, ok := next()
+		if !ok || line == "" {
+			if len(t.Values) == 0 {
+				return fmt.Errorf("line %d: no Value definitions found", *lineNo)
+			}
+			return nil
+		}
+		if commentRegexp.MatchString(line) {
+			continue
+		}
+		if !strings.HasPrefix(line, "Value ") {
+			if len(t.Values) == 0 {
+				return fmt.Errorf("line %d: no Value definitions found", *lineNo)
+			}
+			return fmt.Errorf("line %d: expected blank line after last Value entry", *lineNo)
+		}
+
+		v, err := parseValue(line)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", *lineNo, err)
+		}
+		if _, found := t.index[v.Name]; found {
+			return fmt.Errorf("line %d: duplicate Value %s", *lineNo, v.Name)
+		}
+		t.index[v.Name] = len(t.Values)
+		t.Values = append(t.Values, v)
+	}
+}
+
+func parseValue(line string) (*Value, error) {
+	fields := strings.Split(line, " ")
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("expected 'Value [Options] Name (regex)': %q", line)
+	}
+
+	v := &Value{}
+	if strings.HasPrefix(fields[2], "(") {
+		v.Name = fields[1]
+		v.Regex = strings.Join(fields[2:], " ")
+	} else {
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("expected 'Value [Options] Name (regex)': %q", line)
+		}
+		v.Options = strings.Split(fields[1], ",")
+		v.Name = fields[2]
+		v.Regex = strings.Join(fields[3:], " ")
+	}
+
+	if !valueNameRegexp.MatchString(v.Name) {
+		return nil, fmt.Errorf("invalid Value name %q", v.Name)
+	}
+	for _, o := range v.Options {
+		switch o {
+		case Filldown, Key, Required, List, Fillup:
+		default:
+			return nil, fmt.Errorf("unknown option %q of Value %s", o, v.Name)
+		}
+	}
+	if !strings.HasPrefix(v.Regex, "(") || !strings.HasSuffix(v.Regex, ")") || strings.HasSuffix(v.Regex, `\)`) {
+		return nil, fmt.Errorf("regex of Value %s must be enclosed in parentheses: %q", v.Name, v.Regex)
+	}
+	if _, err := regexp.Compile(v.Regex); err != nil {
+		return nil, fmt.Errorf("invalid regex of Value %s: %w", v.Name, err)
+	}
+	v.template = "(?P<" + v.Name + ">" + v.Regex[1:]
+
+	return v, nil
+}
+
+func (t *Template) parseStates(next func() (string, bool), lineNo *int) error {
+	var current string
+	for {
+		line, ok := next()
+		if !ok {
+			return nil
+		}
+
+		switch {
+		case line == "":
+			current = ""
+		case commentRegexp.MatchString(line):
+		case current == "":
+			m := stateNameRegexp.FindStringSubmatch(line)
+			if m == nil {
+				return fmt.Errorf("line %d: invalid state name %q", *lineNo, line)
+			}
+			if _, found := t.States[m[1]]; found {
+				return fmt.Errorf("line %d: duplicate state %s", *lineNo, m[1])
+			}
+			current = m[1]
+			t.States[current] = nil
+		case ruleLineRegexp.MatchString(line):
+			r, err := t.parseRule(strings.TrimSpace(line))
+			if err != nil {
+				return fmt.Errorf("line %d: %w", *lineNo, err)
+			}
+			t.States[current] = append(t.States[current], r)
+		default:
+			return fmt.Errorf("line %d: expected rule starting with ' ^' in state %s: %q", *lineNo, current, line)
+		}
+	}
+}
+
+func (t *Template) parseRule(line string) (*Rule, error) {
+	r := &Rule{Match: line, LineOp: lineNext, RecordOp: recordNone}
+
+	if m := matchAction.FindStringSubmatch(line); m != nil {
+		r.Match = m[1]
+		action := m[3]
+		if a := actionRegexp.FindStringSubmatch(action); a != nil {
+			r.LineOp = a[1]
+			if a[2] != "" {
+				r.RecordOp = a[2]
+			}
+			r.NewState = a[3]
+		} else if a := action2Regexp.FindStringSubmatch(action); a != nil {
+			r.RecordOp = a[1]
+			r.NewState = a[2]
+		} else if a := action3Regexp.FindStringSubmatch(action); a != nil {
+			r.NewState = a[1]
+		} else {
+			return nil, fmt.Errorf("invalid action %q", action)
+		}
+	}
+
+	if r.LineOp == lineContinue && r.NewState != "" {
+		return nil, fmt.Errorf("action Continue must not change the state: %q", line)
+	}
+	if r.LineOp != lineError && strings.HasPrefix(r.NewState, `"`) {
+		return nil, fmt.Errorf("invalid state name %s", r.NewState)
+	}
+
+	var err error
+	expanded := varRegexp.ReplaceAllStringFunc(r.Match, func(s string) string {
+		if s == "$$" {
+			return "$"
+		}
+		m := varRegexp.FindStringSubmatch(s)
+		name := m[1] + m[2]
+		i, found := t.index[name]
+		if !found {
+			err = fmt.Errorf("unknown Value %s in rule %q", name, line)
+			return s
+		}
+		return t.Values[i].template
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.regex, err = regexp.Compile(expanded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule %q: %w", line, err)
+	}
+
+	return r, nil
+}
+
+func (t *Template) validate() error {
+	if _, found := t.States[StateStart]; !found {
+		return fmt.Errorf("missing state %s", StateStart)
+	}
+	if rules := t.States[StateEnd]; len(rules) > 0 {
+		return fmt.Errorf("state %s must be empty", StateEnd)
+	}
+	if rules := t.States[StateEOF]; len(rules) > 0 {
+		return fmt.Errorf("state %s must be empty", StateEOF)
+	}
+
+	for name, rules := range t.States {
+		for _, r := range rules {
+			if r.NewState == "" || r.LineOp == lineError || r.NewState == StateEnd || r.NewState == StateEOF {
+				continue
+			}
+			if _, found := t.States[r.NewState]; !found {
+				return fmt.Errorf("state %s: unknown new state %s", name, r.NewState)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Header returns the names of the values
+func (t *Template) Header() []string {
+	names := make([]string, len(t.Values))
+	for i, v := range t.Values {
+		names[i] = v.Name
+	}
+
+	return names
+}
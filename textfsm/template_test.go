@@ -0,0 +1,102 @@
+package textfsm
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseInvalidTemplates(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		err      string
+	}{
+		{
+			name:     "empty",
+			template: "",
+			err:      "no Value definitions found",
+		},
+		{
+			name:     "no values",
+			template: "Start\n  ^foo -> Record\n",
+			err:      "no Value definitions found",
+		},
+		{
+			name:     "missing blank line",
+			template: "Value NAME (\\S+)\nStart\n  ^${NAME} -> Record\n",
+			err:      "expected blank line after last Value entry",
+		},
+		{
+			name:     "regex without parentheses",
+			template: "Value NAME (\\S+ x\n\nStart\n  ^${NAME} -> Record\n",
+			err:      "must be enclosed in parentheses",
+		},
+		{
+			name:     "invalid value regex",
+			template: "Value NAME (\\S+(?<=x))\n\nStart\n  ^${NAME} -> Record\n",
+			err:      "invalid regex of Value NAME",
+		},
+		{
+			name:     "unknown option",
+			template: "Value Sticky NAME (\\S+)\n\nStart\n  ^${NAME} -> Record\n",
+			err:      `unknown option "Sticky"`,
+		},
+		{
+			name:     "duplicate value",
+			template: "Value NAME (\\S+)\nValue NAME (\\d+)\n\nStart\n  ^${NAME} -> Record\n",
+			err:      "duplicate Value NAME",
+		},
+		{
+			name:     "missing Start",
+			template: "Value NAME (\\S+)\n\nBegin\n  ^${NAME} -> Record\n",
+			err:      "missing state Start",
+		},
+		{
+			name:     "unknown value in rule",
+			template: "Value NAME (\\S+)\n\nStart\n  ^${OTHER} -> Record\n",
+			err:      "unknown Value OTHER",
+		},
+		{
+			name:     "unknown new state",
+			template: "Value NAME (\\S+)\n\nStart\n  ^${NAME} -> Record Details\n",
+			err:      "unknown new state Details",
+		},
+		{
+			name:     "continue with new state",
+			template: "Value NAME (\\S+)\n\nStart\n  ^${NAME} -> Continue Start\n",
+			err:      "action Continue must not change the state",
+		},
+		{
+			name:     "invalid action",
+			template: "Value NAME (\\S+)\n\nStart\n  ^${NAME} -> Record.Next\n",
+			err:      "invalid action",
+		},
+		{
+			name:     "rule without caret",
+			template: "Value NAME (\\S+)\n\nStart\n  ${NAME} -> Record\n",
+			err:      "expected rule starting with ' ^'",
+		},
+		{
+			name:     "non-empty End",
+			template: "Value NAME (\\S+)\n\nStart\n  ^${NAME} -> Record\n\nEnd\n  ^foo\n",
+			err:      "state End must be empty",
+		},
+		{
+			name:     "duplicate state",
+			template: "Value NAME (\\S+)\n\nStart\n  ^${NAME} -> Record\n\nStart\n  ^foo\n",
+			err:      "duplicate state Start",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(test.template))
+			if err == nil {
+				t.Fatalf("expected an error containing %q", test.err)
+			}
+			if !strings.Contains(err.Error(), test.err) {
+				t.Errorf("got error %q, expected it to contain %q", err, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package transcript
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/shenjler/ssh_ping_exporter/connector"
+)
+
+// Recorder records every command sent through a transport. The transcript is written when the recorder is closed.
+// Transports able to return the raw output have it recorded before it is sanitized.
+// Batches are recorded as one exchange, what the device printed at login is recorded if the transport knows it.
+type Recorder struct {
+	transport  connector.Transport
+	raw        connector.RawTransport
+	batch      connector.BatchTransport
+	rawBatch   connector.RawBatchTransport
+	path       string
+	mu         sync.Mutex
+	transcript *Transcript
+}
+
+// NewRecorder creates a recorder writing the transcript of transport to path
+func NewRecorder(transport connector.Transport, path string) *Recorder {
+	raw, _ := transport.(connector.RawTransport)
+	batch, _ := transport.(connector.BatchTransport)
+	rawBatch, _ := transport.(connector.RawBatchTransport)
+
+	r := &Recorder{
+		transport: transport,
+		raw:       raw,
+		batch:     batch,
+		rawBatch:  rawBatch,
+		path:      path,
+		transcript: &Transcript{
+			Device:   transport.String(),
+			Recorded: time.Now(),
+			Raw:      raw != nil,
+		},
+	}
+	if l, ok := transport.(connector.LoginInfoTransport); ok {
+		info := l.LoginInfo()
+		r.transcript.Login = &info
+	}
+
+	return r
+}
+
+// RunCommand runs a command on the underlying transport and records it
+func (r *Recorder) RunCommand(ctx context.Context, cmd string) (string, error) {
+	t := time.Now()
+	var output string
+	var err error
+	if r.raw != nil {
+		output, err = r.raw.RunCommandRaw(ctx, cmd)
+	} else {
+		output, err = r.transport.RunCommand(ctx, cmd)
+	}
+
+	e := Exchange{
+		Command:  cmd,
+		Output:   output,
+		Started:  t,
+		Duration: time.Since(t),
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+
+	r.record(e)
+
+	if r.raw != nil {
+		output = r.raw.Sanitize(output, cmd)
+	}
+
+	return output, err
+}
+
+// RunCommands runs cmds in one exchange on the underlying transport and records them as one exchange.
+// Transports without batches, or without raw batches if the transcript is raw, get the commands one after another.
+func (r *Recorder) RunCommands(ctx context.Context, cmds []string) ([]string, error) {
+	t := time.Now()
+	var outputs []string
+	var err error
+	switch {
+	case r.rawBatch != nil:
+		outputs, err = r.rawBatch.RunCommandsRaw(ctx, cmds)
+	case r.batch != nil && r.raw == nil:
+		outputs, err = r.batch.RunCommands(ctx, cmds)
+	default:
+		return r.runSequentially(ctx, cmds)
+	}
+
+	e := Exchange{
+		Commands: cmds,
+		Outputs:  outputs,
+		Started:  t,
+		Duration: time.Since(t),
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	r.record(e)
+
+	if err != nil {
+		return nil, err
+	}
+	if r.rawBatch != nil {
+		result := make([]string, len(outputs))
+		for i, output := range outputs {
+			result[i] = r.rawBatch.Sanitize(output, cmds[i])
+		}
+		outputs = result
+	}
+
+	return outputs, nil
+}
+
+func (r *Recorder) runSequentially(ctx context.Context, cmds []string) ([]string, error) {
+	result := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		out, err := r.RunCommand(ctx, cmd)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = out
+	}
+
+	return result, nil
+}
+
+func (r *Recorder) record(e Exchange) {
+	r.mu.Lock()
+	r.transcript.Exchanges = append(r.transcript.Exchanges, e)
+	r.mu.Unlock()
+}
+
+// LoginInfo returns what the device printed at login as known by the underlying transport
+func (r *Recorder) LoginInfo() connector.LoginInfo {
+	if r.transcript.Login == nil {
+		return connector.LoginInfo{}
+	}
+
+	return *r.transcript.Login
+}
+
+// Close closes the underlying transport and writes the transcript
+func (r *Recorder) Close() {
+	r.transport.Close()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := r.transcript.Save(r.path)
+	if err != nil {
+		log.Printf("Could not write transcript of %s: %s", r.transcript.Device, err)
+	}
+}
+
+func (r *Recorder) String() string {
+	return r.transport.String()
+}
+
+// SetOS passes the OS on to the underlying transport
+func (r *Recorder) SetOS(os string) {
+	r.transport.SetOS(os)
+}
@@ -0,0 +1,118 @@
+package transcript
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shenjler/ssh_ping_exporter/connector"
+)
+
+// Replay serves the outputs of a recorded transcript instead of talking to a device
+type Replay struct {
+	transcript *Transcript
+	timing     bool
+	sanitizer  *connector.OutputSanitizer
+	mu         sync.Mutex
+	next       map[string]int
+}
+
+// OpenReplay loads the transcript at path. With timing set, every command takes as long as it did when recorded.
+// Raw outputs are cleaned by sanitizer like the outputs of a live session.
+func OpenReplay(path string, timing bool, sanitizer *connector.OutputSanitizer) (*Replay, error) {
+	t, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if t.Raw && sanitizer == nil {
+		return nil, errors.Errorf("transcript %s has raw outputs but no sanitizer", path)
+	}
+
+	return &Replay{
+		transcript: t,
+		timing:     timing,
+		sanitizer:  sanitizer,
+		next:       make(map[string]int),
+	}, nil
+}
+
+// RunCommand returns the recorded output of cmd. Commands recorded more than once are replayed in order, the last recording is repeated.
+// Commands recorded in a batch are replayed one by one.
+func (r *Replay) RunCommand(ctx context.Context, cmd string) (string, error) {
+	e, found := r.lookup(cmd)
+	if !found {
+		return "", errors.Errorf("command %q not found in transcript of %s", cmd, r.transcript.Device)
+	}
+
+	if r.timing && e.Duration > 0 {
+		timer := time.NewTimer(e.Duration)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	output := e.Output
+	if r.transcript.Raw {
+		output = r.sanitizer.Clean(output, cmd)
+	}
+
+	if e.Error != "" {
+		return output, errors.New(e.Error)
+	}
+
+	return output, nil
+}
+
+func (r *Replay) lookup(cmd string) (Exchange, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matches []Exchange
+	for _, exchange := range r.transcript.Exchanges {
+		for _, e := range exchange.split() {
+			if e.Command == cmd {
+				matches = append(matches, e)
+			}
+		}
+	}
+	if len(matches) == 0 {
+		return Exchange{}, false
+	}
+
+	i := r.next[cmd]
+	if i >= len(matches) {
+		i = len(matches) - 1
+	}
+	r.next[cmd] = i + 1
+
+	return matches[i], true
+}
+
+// LoginInfo returns what the device printed at login, false if it was not recorded
+func (r *Replay) LoginInfo() (connector.LoginInfo, bool) {
+	if r.transcript.Login == nil {
+		return connector.LoginInfo{}, false
+	}
+
+	return *r.transcript.Login, true
+}
+
+// Close does nothing, there is no connection to close
+func (r *Replay) Close() {
+}
+
+// SetOS selects the sanitizer rules of os for raw transcripts, transcripts without raw outputs are already sanitized
+func (r *Replay) SetOS(os string) {
+	if r.sanitizer != nil {
+		r.sanitizer.SetOS(os)
+	}
+}
+
+func (r *Replay) String() string {
+	return r.transcript.Device
+}
@@ -0,0 +1,107 @@
+package transcript
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shenjler/ssh_ping_exporter/connector"
+	"gopkg.in/yaml.v2"
+)
+
+// Transcript is the recorded conversation with one device
+type Transcript struct {
+	Device   string    `json:"device" yaml:"device"`
+	Recorded time.Time `json:"recorded" yaml:"recorded"`
+	// Raw is set if the outputs are recorded as sent by the device, they are sanitized when replayed
+	Raw bool `json:"raw,omitempty" yaml:"raw,omitempty"`
+	// Login is what the device printed at login, if the transport knew it
+	Login     *connector.LoginInfo `json:"login,omitempty" yaml:"login,omitempty"`
+	Exchanges []Exchange           `json:"exchanges" yaml:"exchanges"`
+}
+
+// Exchange is one command sent to the device along with its raw output
+type Exchange struct {
+	Command string `json:"command" yaml:"command"`
+	Output  string `json:"output" yaml:"output"`
+	// Commands and Outputs are set instead of Command and Output for commands sent back-to-back in one exchange
+	Commands []string      `json:"commands,omitempty" yaml:"commands,omitempty"`
+	Outputs  []string      `json:"outputs,omitempty" yaml:"outputs,omitempty"`
+	Error    string        `json:"error,omitempty" yaml:"error,omitempty"`
+	Started  time.Time     `json:"started" yaml:"started"`
+	Duration time.Duration `json:"duration" yaml:"duration"`
+}
+
+// split returns the commands of a batch as exchanges of their own, they share the duration of the batch.
+// Other exchanges are returned as they are.
+func (e Exchange) split() []Exchange {
+	if len(e.Commands) == 0 {
+		return []Exchange{e}
+	}
+
+	result := make([]Exchange, len(e.Commands))
+	for i, cmd := range e.Commands {
+		result[i] = Exchange{Command: cmd, Error: e.Error, Started: e.Started, Duration: e.Duration / time.Duration(len(e.Commands))}
+		if i < len(e.Outputs) {
+			result[i].Output = e.Outputs[i]
+		}
+	}
+
+	return result
+}
+
+// FileName returns the path of the transcript of a device in dir
+func FileName(dir, address string) string {
+	return filepath.Join(dir, strings.NewReplacer(":", "_", "/", "_").Replace(address)+".json")
+}
+
+// Load reads a transcript from a JSON or YAML (.yml, .yaml) file
+func Load(path string) (*Transcript, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Transcript{}
+	if isYAML(path) {
+		err = yaml.Unmarshal(b, t)
+	} else {
+		err = json.Unmarshal(b, t)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not parse transcript %s", path)
+	}
+
+	return t, nil
+}
+
+// Save writes the transcript to path. The file is replaced atomically so readers never see a partial transcript.
+func (t *Transcript) Save(path string) error {
+	var b []byte
+	var err error
+	if isYAML(path) {
+		b, err = yaml.Marshal(t)
+	} else {
+		b, err = json.MarshalIndent(t, "", "  ")
+	}
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	err = ioutil.WriteFile(tmp, b, 0640)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+func isYAML(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".yml" || ext == ".yaml"
+}
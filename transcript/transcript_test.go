@@ -0,0 +1,251 @@
+package transcript
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shenjler/ssh_ping_exporter/config"
+	"github.com/shenjler/ssh_ping_exporter/connector"
+)
+
+// rawTransport answers with the echo of the command, the output and the prompt like a device with a PTY does
+type rawTransport struct {
+	outputs   map[string]string
+	sanitizer *connector.OutputSanitizer
+	login     connector.LoginInfo
+	batches   int
+}
+
+func (r *rawTransport) RunCommandRaw(ctx context.Context, cmd string) (string, error) {
+	return cmd + "\n" + r.outputs[cmd] + "\nrouter#", nil
+}
+
+func (r *rawTransport) RunCommandsRaw(ctx context.Context, cmds []string) ([]string, error) {
+	r.batches++
+	result := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		result[i], _ = r.RunCommandRaw(ctx, cmd)
+	}
+
+	return result, nil
+}
+
+func (r *rawTransport) RunCommands(ctx context.Context, cmds []string) ([]string, error) {
+	result, err := r.RunCommandsRaw(ctx, cmds)
+	for i, cmd := range cmds {
+		result[i] = r.Sanitize(result[i], cmd)
+	}
+
+	return result, err
+}
+
+func (r *rawTransport) LoginInfo() connector.LoginInfo {
+	return r.login
+}
+
+func (r *rawTransport) RunCommand(ctx context.Context, cmd string) (string, error) {
+	out, err := r.RunCommandRaw(ctx, cmd)
+	return r.Sanitize(out, cmd), err
+}
+
+func (r *rawTransport) Sanitize(output, cmd string) string {
+	return r.sanitizer.Clean(output, cmd)
+}
+
+func (r *rawTransport) Close() {
+}
+
+func (r *rawTransport) String() string {
+	return "router:22"
+}
+
+func (r *rawTransport) SetOS(os string) {
+	r.sanitizer.SetOS(os)
+}
+
+func newSanitizer(t *testing.T) *connector.OutputSanitizer {
+	s, err := connector.NewOutputSanitizer(config.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "transcript")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	outputs := map[string]string{
+		"show version": "Cisco IOS XE Software, Version 16.09.04",
+		"show clock":   "*10:00:00.000 UTC Sun Oct 18 2026",
+	}
+	path := FileName(dir, "router:22")
+	r := NewRecorder(&rawTransport{outputs: outputs, sanitizer: newSanitizer(t)}, path)
+	for _, cmd := range []string{"show version", "show clock"} {
+		out, err := r.RunCommand(context.Background(), cmd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != outputs[cmd] {
+			t.Errorf("recorder returned %q for %q, expected the sanitized output %q", out, cmd, outputs[cmd])
+		}
+	}
+	r.Close()
+
+	recorded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !recorded.Raw {
+		t.Errorf("transcript is not marked raw")
+	}
+	if e := recorded.Exchanges[0]; !strings.HasPrefix(e.Output, "show version\n") || !strings.HasSuffix(e.Output, "router#") {
+		t.Errorf("expected the raw output with echo and prompt, got %q", e.Output)
+	}
+
+	replay, err := OpenReplay(path, false, newSanitizer(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay.SetOS("IOSXE")
+	for cmd, expected := range outputs {
+		out, err := replay.RunCommand(context.Background(), cmd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != expected {
+			t.Errorf("replay returned %q for %q, expected %q", out, cmd, expected)
+		}
+	}
+
+	_, err = replay.RunCommand(context.Background(), "show running-config")
+	if err == nil {
+		t.Errorf("expected an error for a command not in the transcript")
+	}
+}
+
+func TestRecordAndReplayBatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "transcript")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cmds := []string{"show interfaces Gi0/0/0 transceiver", "show interfaces Gi0/0/1 transceiver"}
+	outputs := map[string]string{
+		cmds[0]: "Gi0/0/0      31.2       3.30      35.4      -2.1      -3.4",
+		cmds[1]: "Gi0/0/1      30.8       3.29      34.9      -2.3      -5.0",
+	}
+	login := connector.LoginInfo{Banner: "*** Authorized access only ***", LastLoginTime: "Mon Oct 12 09:14:02 2026", LastLoginSource: "10.0.0.10"}
+	transport := &rawTransport{outputs: outputs, sanitizer: newSanitizer(t), login: login}
+	path := FileName(dir, "router:22")
+	r := NewRecorder(transport, path)
+	out, err := r.RunCommands(context.Background(), cmds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+
+	if transport.batches != 1 {
+		t.Errorf("recorder sent %d batches, expected 1", transport.batches)
+	}
+	for i, cmd := range cmds {
+		if out[i] != outputs[cmd] {
+			t.Errorf("recorder returned %q for %q, expected the sanitized output %q", out[i], cmd, outputs[cmd])
+		}
+	}
+
+	recorded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recorded.Exchanges) != 1 || len(recorded.Exchanges[0].Commands) != len(cmds) {
+		t.Fatalf("expected the batch as one exchange, got %+v", recorded.Exchanges)
+	}
+
+	replay, err := OpenReplay(path, false, newSanitizer(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay.SetOS("IOSXE")
+	for _, cmd := range cmds {
+		out, err := replay.RunCommand(context.Background(), cmd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != outputs[cmd] {
+			t.Errorf("replay returned %q for %q, expected %q", out, cmd, outputs[cmd])
+		}
+	}
+	if got, ok := replay.LoginInfo(); !ok || got != login {
+		t.Errorf("replay returned login %+v, %v, expected %+v", got, ok, login)
+	}
+}
+
+func TestReplayOrder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "transcript")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "router_22.yml")
+	tr := &Transcript{
+		Device: "router:22",
+		Exchanges: []Exchange{
+			{Command: "show clock", Output: "10:00"},
+			{Command: "show clock", Output: "10:01", Error: "Timeout reached"},
+		},
+	}
+	err = tr.Save(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replay, err := OpenReplay(path, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		output string
+		err    bool
+	}{
+		{output: "10:00"},
+		{output: "10:01", err: true},
+		{output: "10:01", err: true},
+	}
+	for i, test := range tests {
+		out, err := replay.RunCommand(context.Background(), "show clock")
+		if out != test.output || (err != nil) != test.err {
+			t.Errorf("run %d: got %q, %v; expected %q, error: %v", i, out, err, test.output, test.err)
+		}
+	}
+}
+
+func TestOpenReplayRawWithoutSanitizer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "transcript")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "router_22.json")
+	err = (&Transcript{Device: "router:22", Raw: true}).Save(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = OpenReplay(path, false, nil)
+	if err == nil {
+		t.Errorf("expected an error for a raw transcript without sanitizer")
+	}
+}